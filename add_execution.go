@@ -10,52 +10,81 @@ import (
 
 // AddExecution adds single execution to Builder.
 func (a *API) AddExecution(treeID, deploymentID string, params map[string]interface{}) (Response, error) {
-	baseURL := fmt.Sprintf("%s/v2/tenants/%s/trees/%s/releases/%s/executions",
-		a.apiURL, a.TenantID, treeID, deploymentID)
+	return a.AddExecutionContext(context.Background(), treeID, deploymentID, params)
+}
 
-	var requestBody struct {
-		Parameters      map[string]interface{} `json:"parameters"`
-		InteractionType string                 `json:"type"`
-	}
+// AddExecutionContext adds single execution to Builder using the given context.
+func (a *API) AddExecutionContext(ctx context.Context, treeID, deploymentID string,
+	params map[string]interface{}) (Response, error) {
+	return a.call(ctx, &Request{
+		Operation: OperationExecution,
+		TreeID:    treeID,
+		ReleaseID: deploymentID,
+		Params:    params,
+	})
+}
 
-	requestBody.Parameters = params
-	requestBody.InteractionType = "sync"
+// AddAsyncExecution adds single execution to Builder.
+func (a *API) AddAsyncExecution(treeID, deploymentID string, params map[string]interface{}) (string, error) {
+	return a.AddAsyncExecutionContext(context.Background(), treeID, deploymentID, params)
+}
 
-	body, err := json.Marshal(requestBody)
+// AddAsyncExecutionContext adds single async execution to Builder using the given context.
+func (a *API) AddAsyncExecutionContext(ctx context.Context, treeID, deploymentID string,
+	params map[string]interface{}) (string, error) {
+	response, err := a.AddAsyncExecutionResponseContext(ctx, treeID, deploymentID, params)
 	if err != nil {
-		return Response{}, fmt.Errorf("%w", err)
+		return "", err
 	}
 
-	request, err := http.NewRequestWithContext(context.TODO(), http.MethodPost, baseURL, bytes.NewReader(body))
-	if err != nil {
-		return Response{}, fmt.Errorf("%w", err)
-	}
+	return response.RequestID, nil
+}
 
-	return a.builderBaseSyncRequest(context.TODO(), request)
+// AddAsyncExecutionResponse adds single async execution to Builder, see
+// AddAsyncExecutionResponseContext.
+func (a *API) AddAsyncExecutionResponse(treeID, deploymentID string,
+	params map[string]interface{}) (Response, error) {
+	return a.AddAsyncExecutionResponseContext(context.Background(), treeID, deploymentID, params)
 }
 
-// AddAsyncExecution adds single execution to Builder.
-func (a *API) AddAsyncExecution(treeID, deploymentID string, params map[string]interface{}) (string, error) {
+// AddAsyncExecutionResponseContext adds single async execution to Builder
+// using the given context. The Response only holds the request, session and
+// trace IDs and the idempotency key of the execution.
+func (a *API) AddAsyncExecutionResponseContext(ctx context.Context, treeID, deploymentID string,
+	params map[string]interface{}) (Response, error) {
+	return a.call(ctx, &Request{
+		Operation: OperationAsyncExecution,
+		TreeID:    treeID,
+		ReleaseID: deploymentID,
+		Params:    params,
+	})
+}
+
+func (a *API) executionRequest(ctx context.Context, req *Request) (*http.Request, error) {
 	baseURL := fmt.Sprintf("%s/v2/tenants/%s/trees/%s/releases/%s/executions",
-		a.apiURL, a.TenantID, treeID, deploymentID)
+		a.apiURL, a.TenantID, req.TreeID, req.ReleaseID)
 
 	var requestBody struct {
 		Parameters      map[string]interface{} `json:"parameters"`
 		InteractionType string                 `json:"type"`
 	}
 
-	requestBody.Parameters = params
-	requestBody.InteractionType = "async"
+	requestBody.Parameters = req.Params
+	requestBody.InteractionType = "sync"
+
+	if req.Operation == OperationAsyncExecution {
+		requestBody.InteractionType = "async"
+	}
 
 	body, err := json.Marshal(requestBody)
 	if err != nil {
-		return "", fmt.Errorf("%w", err)
+		return nil, fmt.Errorf("%w", err)
 	}
 
-	request, err := http.NewRequestWithContext(context.TODO(), http.MethodPost, baseURL, bytes.NewReader(body))
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL, bytes.NewReader(body))
 	if err != nil {
-		return "", fmt.Errorf("%w", err)
+		return nil, fmt.Errorf("%w", err)
 	}
 
-	return a.builderBaseAsyncRequest(context.TODO(), request)
+	return request, nil
 }
@@ -1,11 +1,14 @@
 package builder
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -88,6 +91,7 @@ func TestAddExecutions200(t *testing.T) {
 	payloadResponse := Response{
 		SessionID:    "c563cd9a979c46c18d8d892b122f5e38",
 		RequestID:    "c563cd9a979c46c18d8d892b122f5e39",
+		TraceID:      "c563cd9a979c46c18d8d892b122f5e40",
 		TreeVersion:  "3",
 		ResponseType: "COMMON",
 		Data: ResponseData{
@@ -119,42 +123,42 @@ func TestAddSyncExecutionsError(t *testing.T) {
 			"tenant not found",
 			"text/html",
 			"not found",
-			errTenantNotFound.Error(),
+			ErrTenantNotFound.Error(),
 			http.StatusNotFound,
 		},
 		{
 			"rate limit",
 			"text/html",
 			"rate limit",
-			errRateLimit.Error(),
+			ErrRateLimit.Error(),
 			http.StatusServiceUnavailable,
 		},
 		{
 			"tree not found",
 			"application/json",
 			"tree_not_found",
-			errTreeNotFound.Error(),
+			ErrTreeNotFound.Error(),
 			http.StatusNotFound,
 		},
 		{
 			"release not found",
 			"application/json",
 			"function_not_found",
-			errReleaseNotFound.Error(),
+			ErrReleaseNotFound.Error(),
 			http.StatusNotFound,
 		},
 		{
 			"api key format",
 			"application/json",
 			"authorization header format must be Bearer {token}",
-			errAPIKeyFormat.Error(),
+			ErrAPIKeyFormat.Error(),
 			http.StatusBadRequest,
 		},
 		{
 			"permissions",
 			"application/json",
 			"not_allowd",
-			errPermissions.Error(),
+			ErrPermissions.Error(),
 			http.StatusForbidden,
 		},
 	}
@@ -298,42 +302,42 @@ func TestAddAsyncExecutionsError(t *testing.T) {
 			"tenant not found",
 			"text/html",
 			"not found",
-			errTenantNotFound.Error(),
+			ErrTenantNotFound.Error(),
 			http.StatusNotFound,
 		},
 		{
 			"rate limit",
 			"text/html",
 			"rate limit",
-			errRateLimit.Error(),
+			ErrRateLimit.Error(),
 			http.StatusServiceUnavailable,
 		},
 		{
 			"tree not found",
 			"application/json",
 			"tree_not_found",
-			errTreeNotFound.Error(),
+			ErrTreeNotFound.Error(),
 			http.StatusNotFound,
 		},
 		{
 			"release not found",
 			"application/json",
 			"function_not_found",
-			errReleaseNotFound.Error(),
+			ErrReleaseNotFound.Error(),
 			http.StatusNotFound,
 		},
 		{
 			"api key format",
 			"application/json",
 			"authorization header format must be Bearer {token}",
-			errAPIKeyFormat.Error(),
+			ErrAPIKeyFormat.Error(),
 			http.StatusBadRequest,
 		},
 		{
 			"permissions",
 			"application/json",
 			"not_allowd",
-			errPermissions.Error(),
+			ErrPermissions.Error(),
 			http.StatusForbidden,
 		},
 	}
@@ -396,3 +400,37 @@ func TestAddAsyncExecutionsError(t *testing.T) {
 		})
 	}
 }
+
+func TestAddExecutionContextCanceled(t *testing.T) {
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+
+	defer server.Close()
+	defer close(release)
+
+	client := New("aabbcc", "my_tenant_1312")
+	client.apiURL = server.URL
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	parameters := map[string]interface{}{
+		"color": "red",
+	}
+
+	_, err := client.AddExecutionContext(ctx, "color_pick", "production", parameters)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want [%v] got [%v]", context.DeadlineExceeded, err)
+	}
+
+	_, err = client.AddAsyncExecutionContext(ctx, "color_pick", "production", parameters)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want [%v] got [%v]", context.DeadlineExceeded, err)
+	}
+}
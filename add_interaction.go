@@ -10,26 +10,41 @@ import (
 
 // AddInteraction adds an interaction for a session.
 func (a *API) AddInteraction(sessionID, interactionType string, params map[string]interface{}) (Response, error) {
+	return a.AddInteractionContext(context.Background(), sessionID, interactionType, params)
+}
+
+// AddInteractionContext adds an interaction for a session using the given context.
+func (a *API) AddInteractionContext(ctx context.Context, sessionID, interactionType string,
+	params map[string]interface{}) (Response, error) {
+	return a.call(ctx, &Request{
+		Operation:       OperationInteraction,
+		SessionID:       sessionID,
+		InteractionType: interactionType,
+		Params:          params,
+	})
+}
+
+func (a *API) interactionRequest(ctx context.Context, req *Request) (*http.Request, error) {
 	baseURL := fmt.Sprintf("%s/v2/tenants/%s/executions/%s/interactions",
-		a.apiURL, a.TenantID, sessionID)
+		a.apiURL, a.TenantID, req.SessionID)
 
 	var requestBody struct {
 		Parameters      map[string]interface{} `json:"parameters"`
 		InteractionType string                 `json:"type"`
 	}
 
-	requestBody.Parameters = params
-	requestBody.InteractionType = interactionType
+	requestBody.Parameters = req.Params
+	requestBody.InteractionType = req.InteractionType
 
 	body, err := json.Marshal(requestBody)
 	if err != nil {
-		return Response{}, fmt.Errorf("%w", err)
+		return nil, fmt.Errorf("%w", err)
 	}
 
-	request, err := http.NewRequestWithContext(context.TODO(), http.MethodPost, baseURL, bytes.NewReader(body))
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL, bytes.NewReader(body))
 	if err != nil {
-		return Response{}, fmt.Errorf("%w", err)
+		return nil, fmt.Errorf("%w", err)
 	}
 
-	return a.builderBaseSyncRequest(context.TODO(), request)
+	return request, nil
 }
@@ -3,17 +3,16 @@ package builder
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
-	"strings"
+	"time"
 )
 
 const (
 	headerSessionID = "X-Session-Id"
 	headerRequestID = "X-Request-Id"
+	headerTraceID   = "X-Trace-Id"
 )
 
 type builderResponse struct {
@@ -23,119 +22,184 @@ type builderResponse struct {
 }
 
 type builderError struct {
-	Error string `json:"error"`
+	Error   string `json:"error"`
+	Message string `json:"message"`
 }
 
-var (
-	errBuilderAPI      = errors.New("internal_builder_error")
-	errTreeNotFound    = errors.New("tree_not_found")
-	errReleaseNotFound = errors.New("release_not_found")
-	errInvalidAPIKey   = errors.New("invalidApiKey")
-	errTenantNotFound  = errors.New("tenant_not_found")
-	errAPIKeyFormat    = errors.New("wrong_api_key_format")
-	errPermissions     = errors.New("not_enough_privileges")
-	errRateLimit       = errors.New("rate_limit_reached")
-)
+func (a *API) setCommonHeaders(request *http.Request) {
+	request.Header.Set("Content-Type", "application/json")
 
-func proc404(err string) error {
-	switch err {
-	case "function_not_found":
-		return errReleaseNotFound
-	case "tree_not_found":
-		return errTreeNotFound
+	userAgent := fmt.Sprintf("builder-go/%s", clientversion)
+	if a.userAgent != "" {
+		userAgent = fmt.Sprintf("%s %s", userAgent, a.userAgent)
 	}
 
-	return errBuilderAPI
-}
-
-func proc400(err string) error {
-	switch err {
-	case "authorization header format must be Bearer {token}":
-		return errAPIKeyFormat
-	case "tree_not_found":
-		return errTreeNotFound
-	}
+	request.Header.Set("User-Agent", userAgent)
 
-	return errBuilderAPI
+	authorizationValue := fmt.Sprintf("Bearer %s", a.apiKey)
+	request.Header.Set("Authorization", authorizationValue)
 }
 
-func procBuilderErrors(status int, err string) error {
-	switch status {
-	case http.StatusNotFound:
-		return proc404(err)
-	case http.StatusUnauthorized:
-		return errInvalidAPIKey
-	case http.StatusForbidden:
-		return errPermissions
-	case http.StatusBadRequest:
-		return proc400(err)
+// send makes a single attempt of request and returns the response with its
+// body already read.
+func (a *API) send(request *http.Request) (*http.Response, []byte, error) {
+	response, err := a.httpClient.Do(request)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w", err)
 	}
 
-	return errBuilderAPI
-}
+	defer func() {
+		err := response.Body.Close()
+		if err != nil && a.logger != nil {
+			a.logger.ErrorContext(request.Context(), "error closing body", "error", err)
+		}
+	}()
 
-func procBalancerError(status int) error {
-	switch status {
-	case http.StatusNotFound:
-		return errTenantNotFound
-	case http.StatusServiceUnavailable:
-		return errRateLimit
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w", err)
 	}
 
-	return errBuilderAPI
+	return response, content, nil
 }
 
-func procErrors(response *http.Response, body []byte) error {
-	contentType := response.Header.Get("Content-Type")
+// do sends request for req, retrying it according to the retry policy of the
+// client. Every attempt waits for the rate limiter of the client and of the tree.
+func (a *API) do(ctx context.Context, req *Request, request *http.Request) (*http.Response, []byte, error) {
+	a.setCommonHeaders(request)
 
-	if strings.Contains(contentType, "text/html") {
-		return procBalancerError(response.StatusCode)
+	key := req.IdempotencyKey
+	if key != "" {
+		request.Header.Set(headerIdempotencyKey, key)
 	}
 
-	var res builderError
+	policy := a.retryPolicy
 
-	if err := json.Unmarshal(body, &res); err != nil {
-		return fmt.Errorf("%w", err)
+	attempts := 1
+	if request.Method == http.MethodGet || key != "" {
+		attempts = policy.MaxAttempts
 	}
 
-	return procBuilderErrors(response.StatusCode, res.Error)
-}
+	for attempt := 1; ; attempt++ {
+		if err := a.limiter.wait(ctx, req.TreeID); err != nil {
+			return nil, nil, fmt.Errorf("%w", err)
+		}
 
-func (a *API) setCommonHeaders(request *http.Request) {
-	request.Header.Set("Content-Type", "application/json")
+		attemptRequest := request.WithContext(ctx)
 
-	userAgent := fmt.Sprintf("builder-go/%s", clientversion)
+		if attempt > 1 && request.GetBody != nil {
+			body, err := request.GetBody()
+			if err != nil {
+				return nil, nil, fmt.Errorf("%w", err)
+			}
 
-	request.Header.Set("User-Agent", userAgent)
+			attemptRequest.Body = body
+		}
 
-	authorizationValue := fmt.Sprintf("Bearer %s", a.apiKey)
-	request.Header.Set("Authorization", authorizationValue)
+		start := time.Now()
+		response, content, err := a.send(attemptRequest)
+		a.logAttempt(ctx, req, attemptRequest, attempt, response, time.Since(start), err)
+
+		if tries := attemptsFromContext(ctx); tries != nil {
+			tries.count = attempt
+			tries.statusCode = 0
+
+			if response != nil {
+				tries.statusCode = response.StatusCode
+			}
+		}
+
+		if attempt >= attempts || ctx.Err() != nil {
+			return response, content, err
+		}
+
+		if err != nil && !retryableError(err) {
+			return response, content, err
+		}
+
+		if err == nil && !retryableStatus(response.StatusCode) {
+			return response, content, nil
+		}
+
+		wait := policy.backoff(attempt)
+
+		if err == nil {
+			if after := retryAfter(response); after > 0 {
+				if policy.MaxBackoff > 0 && after > policy.MaxBackoff {
+					return response, content, nil
+				}
+
+				wait = after
+			}
+		}
+
+		if sleepErr := sleep(ctx, wait); sleepErr != nil {
+			return response, content, err
+		}
+	}
 }
 
-func (a *API) builderBaseSyncRequest(ctx context.Context, request *http.Request) (Response, error) {
-	a.setCommonHeaders(request)
+// builderBaseRequest is the Handler at the end of the middleware chain, it
+// sends req to Builder.
+func (a *API) builderBaseRequest(ctx context.Context, req *Request) (Response, error) {
+	var (
+		request *http.Request
+		err     error
+	)
+
+	switch req.Operation {
+	case OperationExecution, OperationAsyncExecution:
+		request, err = a.executionRequest(ctx, req)
+	case OperationInteraction:
+		request, err = a.interactionRequest(ctx, req)
+	case OperationSession:
+		request, err = a.sessionRequest(ctx, req)
+	default:
+		return Response{}, fmt.Errorf("%w: %q", ErrUnknownOperation, req.Operation)
+	}
 
-	response, err := a.httpClient.Do(request.WithContext(ctx))
 	if err != nil {
-		return Response{}, fmt.Errorf("%w", err)
+		return Response{}, err
 	}
 
-	defer func() {
-		err := response.Body.Close()
-		if err != nil {
-			log.Printf("error closing body [%v]", err)
+	for name, values := range req.Header {
+		for _, value := range values {
+			request.Header.Add(name, value)
 		}
-	}()
+	}
+
+	var response Response
+
+	if req.Operation == OperationAsyncExecution {
+		response, err = a.builderBaseAsyncRequest(ctx, req, request)
+	} else {
+		response, err = a.builderBaseSyncRequest(ctx, req, request)
+	}
 
-	content, err := io.ReadAll(response.Body)
 	if err != nil {
-		return Response{}, fmt.Errorf("%w", err)
+		return Response{}, err
+	}
+
+	response.IdempotencyKey = req.IdempotencyKey
+
+	return response, nil
+}
+
+func (a *API) builderBaseSyncRequest(ctx context.Context, req *Request,
+	request *http.Request) (Response, error) {
+	response, content, err := a.do(ctx, req, request)
+	if err != nil {
+		return Response{}, err
 	}
 
 	if unacceptableStatusCode := 399; response.StatusCode > unacceptableStatusCode {
 		return Response{}, procErrors(response, content)
 	}
 
+	return parseResponse(response, content)
+}
+
+func parseResponse(response *http.Response, content []byte) (Response, error) {
 	var baseResponse builderResponse
 
 	if err := json.Unmarshal(content, &baseResponse); err != nil {
@@ -148,34 +212,28 @@ func (a *API) builderBaseSyncRequest(ctx context.Context, request *http.Request)
 		ResponseType: baseResponse.ResponseType,
 		SessionID:    response.Header.Get(headerSessionID),
 		RequestID:    response.Header.Get(headerRequestID),
+		TraceID:      response.Header.Get(headerTraceID),
 	}
 
 	return res, nil
 }
 
-func (a *API) builderBaseAsyncRequest(ctx context.Context, request *http.Request) (string, error) {
-	a.setCommonHeaders(request)
-
-	response, err := a.httpClient.Do(request.WithContext(ctx))
+func (a *API) builderBaseAsyncRequest(ctx context.Context, req *Request,
+	request *http.Request) (Response, error) {
+	response, content, err := a.do(ctx, req, request)
 	if err != nil {
-		return "", fmt.Errorf("%w", err)
+		return Response{}, err
 	}
 
-	defer func() {
-		err := response.Body.Close()
-		if err != nil {
-			log.Printf("error closing body [%v]", err)
-		}
-	}()
-
 	if unacceptableStatusCode := 399; response.StatusCode > unacceptableStatusCode {
-		content, err := io.ReadAll(response.Body)
-		if err != nil {
-			return "", fmt.Errorf("%w", err)
-		}
+		return Response{}, procErrors(response, content)
+	}
 
-		return "", procErrors(response, content)
+	res := Response{
+		SessionID: response.Header.Get(headerSessionID),
+		RequestID: response.Header.Get(headerRequestID),
+		TraceID:   response.Header.Get(headerTraceID),
 	}
 
-	return response.Header.Get(headerRequestID), nil
+	return res, nil
 }
@@ -0,0 +1,155 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// defaultBatchConcurrency is the default number of executions of a batch run
+// at the same time.
+const defaultBatchConcurrency = 4
+
+// BatchOptions configures ExecuteBatch.
+type BatchOptions struct {
+	// Concurrency is the number of executions run at the same time, 4 by default.
+	Concurrency int
+	// FailFast cancels the remaining executions after the first error,
+	// otherwise every execution is attempted.
+	FailFast bool
+}
+
+// BatchResult is the outcome of an execution of a batch.
+type BatchResult struct {
+	Response Response
+	Err      error
+}
+
+// BatchFailure is a failed execution of a batch.
+type BatchFailure struct {
+	Index int
+	Err   error
+}
+
+// BatchError is returned by ExecuteBatch when executions failed.
+type BatchError struct {
+	Total int
+	// Failures are sorted by index.
+	Failures []BatchFailure
+}
+
+func (e *BatchError) Error() string {
+	if len(e.Failures) == 0 {
+		return fmt.Sprintf("0 of %d executions failed", e.Total)
+	}
+
+	first := e.Failures[0]
+
+	return fmt.Sprintf("%d of %d executions failed, first at index %d: %v",
+		len(e.Failures), e.Total, first.Index, first.Err)
+}
+
+// Is reports whether the error of a failed execution matches target. It
+// makes errors.Is work before Go 1.20, which ignores Unwrap() []error.
+func (e *BatchError) Is(target error) bool {
+	for _, failure := range e.Failures {
+		if errors.Is(failure.Err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As finds the first error of a failed execution matching target, see Is.
+func (e *BatchError) As(target interface{}) bool {
+	for _, failure := range e.Failures {
+		if errors.As(failure.Err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Unwrap returns the errors of the failed executions.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, failure := range e.Failures {
+		errs[i] = failure.Err
+	}
+
+	return errs
+}
+
+// ExecuteBatch adds a sync execution for every params on a bounded pool of
+// workers. The results keep the order of params, failed executions are also
+// reported in a *BatchError. Executions not started when ctx is done, or
+// after the first error in fail fast mode, fail with the context error.
+func ExecuteBatch(ctx context.Context, client ContextClient, treeID, releaseID string,
+	params []map[string]interface{}, opts BatchOptions) ([]BatchResult, error) {
+	results := make([]BatchResult, len(params))
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for index := range indexes {
+				if err := ctx.Err(); err != nil {
+					results[index].Err = err
+
+					continue
+				}
+
+				response, err := client.AddExecutionContext(ctx, treeID, releaseID, params[index])
+				results[index] = BatchResult{Response: response, Err: err}
+
+				if err != nil && opts.FailFast {
+					cancel()
+				}
+			}
+		}()
+	}
+
+	for index := range params {
+		indexes <- index
+	}
+
+	close(indexes)
+	wg.Wait()
+
+	var failures []BatchFailure
+
+	for index, result := range results {
+		if result.Err != nil {
+			failures = append(failures, BatchFailure{Index: index, Err: result.Err})
+		}
+	}
+
+	if len(failures) > 0 {
+		return results, &BatchError{Total: len(params), Failures: failures}
+	}
+
+	return results, nil
+}
+
+// ExecuteBatch adds a sync execution for every params, see ExecuteBatch.
+func (a *API) ExecuteBatch(ctx context.Context, treeID, releaseID string,
+	params []map[string]interface{}, opts BatchOptions) ([]BatchResult, error) {
+	return ExecuteBatch(ctx, a, treeID, releaseID, params, opts)
+}
@@ -0,0 +1,168 @@
+package builder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newBatchServer(t *testing.T, inFlight, maxInFlight *int32) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(inFlight, 1)
+		defer atomic.AddInt32(inFlight, -1)
+
+		for {
+			peak := atomic.LoadInt32(maxInFlight)
+			if current <= peak || atomic.CompareAndSwapInt32(maxInFlight, peak, current) {
+				break
+			}
+		}
+
+		var requestBody struct {
+			Parameters map[string]interface{} `json:"parameters"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			t.Errorf("Error request body %v", err)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+
+		if requestBody.Parameters["color"] == "black" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+
+			n, err := w.Write([]byte(`{"error": "tree_not_found"}`))
+			if err != nil {
+				t.Errorf("Error writing response httptest Server [%v][%d]", err, n)
+			}
+
+			return
+		}
+
+		response := map[string]interface{}{
+			"response_type": "COMMON",
+			"data":          map[string]interface{}{"vars": requestBody.Parameters},
+		}
+
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			t.Errorf("Error writing response httptest Server [%v]", err)
+		}
+	}))
+}
+
+func TestExecuteBatch(t *testing.T) {
+	var inFlight, maxInFlight int32
+
+	server := newBatchServer(t, &inFlight, &maxInFlight)
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL))
+
+	colors := []string{"red", "green", "black", "blue", "white", "pink"}
+
+	params := make([]map[string]interface{}, len(colors))
+	for i, color := range colors {
+		params[i] = map[string]interface{}{"color": color}
+	}
+
+	results, err := client.ExecuteBatch(context.Background(), "color_pick", "production", params,
+		BatchOptions{Concurrency: 2})
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("want *BatchError got [%v]", err)
+	}
+
+	if len(batchErr.Failures) != 1 || batchErr.Failures[0].Index != 2 ||
+		!errors.Is(batchErr.Failures[0].Err, ErrTreeNotFound) {
+		t.Errorf("want failure at index [2] got [%+v]", batchErr.Failures)
+	}
+
+	for i, color := range colors {
+		if i == 2 {
+			continue
+		}
+
+		if results[i].Err != nil || results[i].Response.Data.Vars["color"] != color {
+			t.Errorf("index [%d] want [%s] got [%+v]", i, color, results[i])
+		}
+	}
+
+	if maxInFlight > 2 {
+		t.Errorf("want at most [2] executions in flight got [%d]", maxInFlight)
+	}
+}
+
+func TestExecuteBatchFailFast(t *testing.T) {
+	var inFlight, maxInFlight int32
+
+	server := newBatchServer(t, &inFlight, &maxInFlight)
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL))
+
+	params := []map[string]interface{}{{"color": "black"}}
+	for i := 0; i < 10; i++ {
+		params = append(params, map[string]interface{}{"color": "red"})
+	}
+
+	results, err := client.ExecuteBatch(context.Background(), "color_pick", "production", params,
+		BatchOptions{Concurrency: 1, FailFast: true})
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || len(batchErr.Failures) != len(params) {
+		t.Fatalf("want every execution failed got [%v]", err)
+	}
+
+	if !errors.Is(results[0].Err, ErrTreeNotFound) {
+		t.Errorf("want [%v] got [%v]", ErrTreeNotFound, results[0].Err)
+	}
+
+	if !errors.Is(results[len(params)-1].Err, context.Canceled) {
+		t.Errorf("want [%v] got [%v]", context.Canceled, results[len(params)-1].Err)
+	}
+}
+
+func TestBatchErrorIs(t *testing.T) {
+	err := fmt.Errorf("scoring: %w", &BatchError{
+		Total: 3,
+		Failures: []BatchFailure{
+			{Index: 0, Err: context.Canceled},
+			{Index: 2, Err: &APIError{StatusCode: http.StatusNotFound, Err: ErrTreeNotFound}},
+		},
+	})
+
+	if !errors.Is(err, ErrTreeNotFound) || !errors.Is(err, context.Canceled) {
+		t.Errorf("want [%v] and [%v] found in [%v]", ErrTreeNotFound, context.Canceled, err)
+	}
+
+	if errors.Is(err, ErrRateLimit) {
+		t.Errorf("want [%v] not found in [%v]", ErrRateLimit, err)
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("want *APIError got [%v]", apiErr)
+	}
+}
+
+func TestBatchErrorWithoutFailures(t *testing.T) {
+	err := &BatchError{Total: 3}
+
+	if got := err.Error(); got != "0 of 3 executions failed" {
+		t.Errorf("want [0 of 3 executions failed] got [%s]", got)
+	}
+
+	if errors.Is(err, ErrTreeNotFound) {
+		t.Errorf("want [%v] not found in [%v]", ErrTreeNotFound, err)
+	}
+}
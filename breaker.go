@@ -0,0 +1,286 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling Builder while the circuit of a
+// tree and release is open.
+var ErrCircuitOpen = errors.New("circuit_open")
+
+// CircuitState is the state of the circuit of a tree and release.
+type CircuitState int
+
+// States of a circuit.
+const (
+	// CircuitClosed lets every call through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects every call with ErrCircuitOpen.
+	CircuitOpen
+	// CircuitHalfOpen lets trial calls through to decide whether to close.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// CircuitBreaker configures the circuit breaker of the client. Zero fields
+// take the values of DefaultCircuitBreaker.
+type CircuitBreaker struct {
+	// FailureThreshold is the number of consecutive failures that opens
+	// the circuit.
+	FailureThreshold int
+	// CoolDown is the time the circuit stays open before letting trial
+	// calls through.
+	CoolDown time.Duration
+	// HalfOpenRequests is the number of concurrent trial calls allowed
+	// while half-open.
+	HalfOpenRequests int
+	// IsFailure reports whether err counts as a failure, by default
+	// internal Builder errors, timeouts, network errors and gateway errors.
+	// Calls whose context ended are never counted.
+	IsFailure func(err error) bool
+	// OnStateChange is called when the circuit of a tree and release
+	// changes state.
+	OnStateChange func(treeID, releaseID string, from, to CircuitState)
+}
+
+// DefaultCircuitBreaker returns the default circuit breaker configuration.
+func DefaultCircuitBreaker() CircuitBreaker {
+	return CircuitBreaker{
+		FailureThreshold: 5,
+		CoolDown:         30 * time.Second,
+		HalfOpenRequests: 1,
+		IsFailure:        isBuilderFailure,
+	}
+}
+
+// WithCircuitBreaker opens a circuit per tree and release when its
+// executions keep failing. Interactions and session lookups are not affected.
+func WithCircuitBreaker(breaker CircuitBreaker) Option {
+	return func(a *API) {
+		defaults := DefaultCircuitBreaker()
+
+		if breaker.FailureThreshold <= 0 {
+			breaker.FailureThreshold = defaults.FailureThreshold
+		}
+
+		if breaker.CoolDown <= 0 {
+			breaker.CoolDown = defaults.CoolDown
+		}
+
+		if breaker.HalfOpenRequests <= 0 {
+			breaker.HalfOpenRequests = defaults.HalfOpenRequests
+		}
+
+		if breaker.IsFailure == nil {
+			breaker.IsFailure = defaults.IsFailure
+		}
+
+		a.breaker = &circuitBreaker{
+			config:   breaker,
+			circuits: make(map[releaseKey]*circuit),
+		}
+	}
+}
+
+// CircuitState returns the state of the circuit of treeID and releaseID,
+// CircuitClosed when the client has no circuit breaker.
+func (a *API) CircuitState(treeID, releaseID string) CircuitState {
+	if a.breaker == nil {
+		return CircuitClosed
+	}
+
+	return a.breaker.state(releaseKey{treeID: treeID, releaseID: releaseID})
+}
+
+func isBuilderFailure(err error) bool {
+	return errors.Is(err, ErrBuilderAPI) || errors.Is(err, context.DeadlineExceeded) || IsRetryable(err)
+}
+
+type releaseKey struct {
+	treeID    string
+	releaseID string
+}
+
+type circuit struct {
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	// trials is the number of trial calls in progress in the half-open
+	// window numbered window.
+	trials int
+	window int
+}
+
+// ticket identifies a call allowed by the circuit breaker.
+type ticket struct {
+	trial  bool
+	window int
+}
+
+type circuitBreaker struct {
+	config CircuitBreaker
+
+	mu       sync.Mutex
+	circuits map[releaseKey]*circuit
+}
+
+type stateChange struct {
+	from, to CircuitState
+}
+
+func (b *circuitBreaker) state(key releaseKey) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[key]
+	if !ok {
+		return CircuitClosed
+	}
+
+	if c.state == CircuitOpen && time.Since(c.openedAt) >= b.config.CoolDown {
+		return CircuitHalfOpen
+	}
+
+	return c.state
+}
+
+// allow reports whether a call for key may go through, a call allowed must
+// be followed by done with the returned ticket.
+func (b *circuitBreaker) allow(key releaseKey) (bool, ticket, []stateChange) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+
+	var changes []stateChange
+
+	if c.state == CircuitOpen && time.Since(c.openedAt) >= b.config.CoolDown {
+		changes = append(changes, c.set(CircuitHalfOpen))
+		c.trials = 0
+		c.window++
+	}
+
+	switch c.state {
+	case CircuitOpen:
+		return false, ticket{}, changes
+	case CircuitHalfOpen:
+		if c.trials >= b.config.HalfOpenRequests {
+			return false, ticket{}, changes
+		}
+
+		c.trials++
+
+		return true, ticket{trial: true, window: c.window}, changes
+	}
+
+	return true, ticket{}, changes
+}
+
+// done records the result of a call allowed for key, count is false when
+// the result says nothing about the release.
+func (b *circuitBreaker) done(key releaseKey, t ticket, err error, count bool) []stateChange {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuits[key]
+
+	current := t.trial && t.window == c.window && c.state == CircuitHalfOpen
+	if current {
+		c.trials--
+	}
+
+	// only the trials of the current window decide a half-open circuit.
+	if !count || (c.state == CircuitHalfOpen && !current) {
+		return nil
+	}
+
+	failure := err != nil && b.config.IsFailure(err)
+
+	switch {
+	case c.state == CircuitHalfOpen && failure:
+		c.openedAt = time.Now()
+
+		return []stateChange{c.set(CircuitOpen)}
+	case c.state == CircuitHalfOpen:
+		c.failures = 0
+
+		return []stateChange{c.set(CircuitClosed)}
+	case c.state == CircuitClosed && failure:
+		c.failures++
+
+		if c.failures >= b.config.FailureThreshold {
+			c.openedAt = time.Now()
+
+			return []stateChange{c.set(CircuitOpen)}
+		}
+	case c.state == CircuitClosed:
+		c.failures = 0
+	}
+
+	return nil
+}
+
+func (b *circuitBreaker) notify(key releaseKey, changes []stateChange) {
+	if b.config.OnStateChange == nil {
+		return
+	}
+
+	for _, change := range changes {
+		b.config.OnStateChange(key.treeID, key.releaseID, change.from, change.to)
+	}
+}
+
+func (c *circuit) set(state CircuitState) stateChange {
+	change := stateChange{from: c.state, to: state}
+	c.state = state
+
+	return change
+}
+
+// breakerMiddleware rejects the executions of trees and releases with an
+// open circuit.
+func (a *API) breakerMiddleware(next Handler) Handler {
+	return func(ctx context.Context, req *Request) (Response, error) {
+		if req.TreeID == "" {
+			return next(ctx, req)
+		}
+
+		key := releaseKey{treeID: req.TreeID, releaseID: req.ReleaseID}
+
+		ok, t, changes := a.breaker.allow(key)
+		a.breaker.notify(key, changes)
+
+		if !ok {
+			return Response{}, fmt.Errorf("%w: tree [%s] release [%s]", ErrCircuitOpen, req.TreeID, req.ReleaseID)
+		}
+
+		response, err := next(ctx, req)
+
+		// calls whose context ended say nothing about the release.
+		count := ctx.Err() == nil
+
+		a.breaker.notify(key, a.breaker.done(key, t, err, count))
+
+		return response, err
+	}
+}
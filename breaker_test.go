@@ -0,0 +1,291 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var (
+		calls   int64
+		healthy int32
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&calls, 1)
+
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			_, err := w.Write([]byte(`{"error": "internal_builder_error"}`))
+			if err != nil {
+				t.Errorf("Error writing response httptest Server [%v]", err)
+			}
+
+			return
+		}
+
+		_, err := w.Write([]byte(`{"tree_version": "3", "response_type": "COMMON"}`))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v]", err)
+		}
+	}))
+	defer server.Close()
+
+	var (
+		mu      sync.Mutex
+		changes []string
+	)
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL), WithCircuitBreaker(CircuitBreaker{
+		FailureThreshold: 2,
+		CoolDown:         50 * time.Millisecond,
+		OnStateChange: func(treeID, releaseID string, from, to CircuitState) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			changes = append(changes, fmt.Sprintf("%s/%s %s->%s", treeID, releaseID, from, to))
+		},
+	}))
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.AddExecution("tree_1", "release_1", nil); !errors.Is(err, ErrBuilderAPI) {
+			t.Fatalf("want [%v] got [%v]", ErrBuilderAPI, err)
+		}
+	}
+
+	if state := client.CircuitState("tree_1", "release_1"); state != CircuitOpen {
+		t.Errorf("want [%s] got [%s]", CircuitOpen, state)
+	}
+
+	if _, err := client.AddExecution("tree_1", "release_1", nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("want [%v] got [%v]", ErrCircuitOpen, err)
+	}
+
+	if _, err := client.AddExecution("tree_1", "release_2", nil); !errors.Is(err, ErrBuilderAPI) {
+		t.Errorf("other releases must not be affected, got [%v]", err)
+	}
+
+	if got := atomic.LoadInt64(&calls); got != 3 {
+		t.Errorf("want [3] calls got [%d]", got)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if _, err := client.AddExecution("tree_1", "release_1", nil); !errors.Is(err, ErrBuilderAPI) {
+		t.Fatalf("want [%v] got [%v]", ErrBuilderAPI, err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	atomic.StoreInt32(&healthy, 1)
+
+	if _, err := client.AddExecution("tree_1", "release_1", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if state := client.CircuitState("tree_1", "release_1"); state != CircuitClosed {
+		t.Errorf("want [%s] got [%s]", CircuitClosed, state)
+	}
+
+	want := []string{
+		"tree_1/release_1 closed->open",
+		"tree_1/release_1 open->half-open",
+		"tree_1/release_1 half-open->open",
+		"tree_1/release_1 open->half-open",
+		"tree_1/release_1 half-open->closed",
+	}
+
+	if diff := cmp.Diff(want, changes); diff != "" {
+		t.Errorf("state changes mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCircuitBreakerIgnoresClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+
+		_, err := w.Write([]byte(`{"error": "tree_not_found"}`))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v]", err)
+		}
+	}))
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL),
+		WithCircuitBreaker(CircuitBreaker{FailureThreshold: 1}))
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.AddExecution("tree_1", "release_1", nil); !errors.Is(err, ErrTreeNotFound) {
+			t.Fatalf("want [%v] got [%v]", ErrTreeNotFound, err)
+		}
+	}
+
+	if state := client.CircuitState("tree_1", "release_1"); state != CircuitClosed {
+		t.Errorf("want [%s] got [%s]", CircuitClosed, state)
+	}
+}
+
+func TestCircuitBreakerClientTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(200 * time.Millisecond):
+		}
+	}))
+	defer server.Close()
+
+	var changes int32
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL), WithTimeout(20*time.Millisecond),
+		WithCircuitBreaker(CircuitBreaker{
+			FailureThreshold: 2,
+			OnStateChange: func(treeID, releaseID string, from, to CircuitState) {
+				atomic.AddInt32(&changes, 1)
+			},
+		}))
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.AddExecution("tree_1", "release_1", nil); err == nil {
+			t.Fatal("want a timeout")
+		}
+	}
+
+	if state := client.CircuitState("tree_1", "release_1"); state != CircuitOpen {
+		t.Errorf("want [%s] got [%s]", CircuitOpen, state)
+	}
+
+	if got := atomic.LoadInt32(&changes); got != 1 {
+		t.Errorf("want [1] state changes got [%d]", got)
+	}
+}
+
+func TestCircuitBreakerIgnoresCallerDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(200 * time.Millisecond):
+		}
+	}))
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL),
+		WithCircuitBreaker(CircuitBreaker{FailureThreshold: 1}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.AddExecutionContext(ctx, "tree_1", "release_1", nil); err == nil {
+		t.Fatal("want a timeout")
+	}
+
+	if state := client.CircuitState("tree_1", "release_1"); state != CircuitClosed {
+		t.Errorf("want [%s] got [%s]", CircuitClosed, state)
+	}
+}
+
+func TestCircuitBreakerHalfOpenTrials(t *testing.T) {
+	var failing int32
+
+	slow := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("Error reading request [%v]", err)
+		}
+
+		if strings.Contains(string(body), "slow") {
+			<-slow
+		}
+
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			_, err = w.Write([]byte(`{"error": "internal_builder_error"}`))
+		} else {
+			_, err = w.Write([]byte(`{"tree_version": "3", "response_type": "COMMON"}`))
+		}
+
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v]", err)
+		}
+	}))
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL), WithCircuitBreaker(CircuitBreaker{
+		FailureThreshold: 1,
+		CoolDown:         30 * time.Millisecond,
+		HalfOpenRequests: 2,
+	}))
+
+	execute := func(speed string) error {
+		_, err := client.AddExecution("tree_1", "release_1", map[string]interface{}{"speed": speed})
+
+		return err
+	}
+
+	// openAndCoolDown opens the circuit and waits for its half-open window.
+	openAndCoolDown := func() {
+		atomic.StoreInt32(&failing, 1)
+
+		if err := execute("fast"); !errors.Is(err, ErrBuilderAPI) {
+			t.Fatalf("want [%v] got [%v]", ErrBuilderAPI, err)
+		}
+
+		time.Sleep(40 * time.Millisecond)
+		atomic.StoreInt32(&failing, 0)
+	}
+
+	// startSlow starts a trial that blocks until slow is written to.
+	startSlow := func() chan error {
+		done := make(chan error, 1)
+
+		go func() {
+			done <- execute("slow")
+		}()
+
+		time.Sleep(20 * time.Millisecond)
+
+		return done
+	}
+
+	openAndCoolDown()
+
+	first := startSlow()
+
+	// the second trial closes the circuit while the first is in progress.
+	if err := execute("fast"); err != nil {
+		t.Fatal(err)
+	}
+
+	slow <- struct{}{}
+
+	if err := <-first; err != nil {
+		t.Fatal(err)
+	}
+
+	openAndCoolDown()
+
+	second := startSlow()
+
+	if err := execute("fast"); err != nil {
+		t.Errorf("want the second trial of the new window allowed got [%v]", err)
+	}
+
+	slow <- struct{}{}
+
+	if err := <-second; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,46 @@
+// Command builder-stub serves scripted trees through a local stand-in of the
+// Builder v2 HTTP API.
+//
+//	builder-stub -addr :8080 -trees trees.yaml
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/reevolute/builder-go/builderstub"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	trees := flag.String("trees", "trees.yaml", "JSON or YAML file defining the tenant and its trees")
+
+	flag.Parse()
+
+	def, err := builderstub.Load(*trees)
+	if err != nil {
+		log.Fatalf("Error loading trees: %v\n", err)
+	}
+
+	stub := builderstub.NewServer(def)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		stub.ServeHTTP(w, r)
+		log.Printf("%s %s %v\n", r.Method, r.URL.Path, time.Since(start))
+	})
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	log.Printf("Serving tenant %s with %d trees on %s\n", def.Tenant, len(def.Trees), *addr)
+
+	if err := server.ListenAndServe(); err != nil {
+		log.Fatalf("Error: %v\n", err)
+	}
+}
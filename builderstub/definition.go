@@ -0,0 +1,126 @@
+package builderstub
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/reevolute/builder-go"
+	"gopkg.in/yaml.v3"
+)
+
+// Definition describes the tenant and the trees served by a Server.
+type Definition struct {
+	// Tenant is the only tenant served, other tenants get the balancer 404 page.
+	Tenant string `json:"tenant" yaml:"tenant"`
+	// APIKey, when set, must be sent as bearer token.
+	APIKey string `json:"api_key" yaml:"api_key"`
+	Trees  []Tree `json:"trees" yaml:"trees"`
+}
+
+// Tree is a scripted tree.
+type Tree struct {
+	ID      string `json:"id" yaml:"id"`
+	Version string `json:"version" yaml:"version"`
+	// Releases served for the tree, any release when empty.
+	Releases   []string    `json:"releases" yaml:"releases"`
+	Executions []Execution `json:"executions" yaml:"executions"`
+}
+
+// Execution maps the parameters of an execution to its result. The first
+// execution whose Match is a subset of the parameters is used.
+type Execution struct {
+	Match    map[string]interface{} `json:"match" yaml:"match"`
+	Response Result                 `json:"response" yaml:"response"`
+	Error    *Error                 `json:"error" yaml:"error"`
+	// Steps are the interactions of the session started by the execution, in order.
+	Steps []Step `json:"steps" yaml:"steps"`
+}
+
+// Step is the expected interaction of a multi-step session.
+type Step struct {
+	Match map[string]interface{} `json:"match" yaml:"match"`
+	// Type is the expected interaction type, any when empty.
+	Type     string `json:"type" yaml:"type"`
+	Response Result `json:"response" yaml:"response"`
+	Error    *Error `json:"error" yaml:"error"`
+}
+
+// Result is the body of a successful response.
+type Result struct {
+	ResponseType string `json:"response_type" yaml:"response_type"`
+	Data         Data   `json:"data" yaml:"data"`
+}
+
+// Data is the data component of a Result.
+type Data struct {
+	Description string                 `json:"description" yaml:"description"`
+	ErrorCode   string                 `json:"error_code" yaml:"error_code"`
+	Vars        map[string]interface{} `json:"vars" yaml:"vars"`
+}
+
+// Error is an error response. Balancer errors are answered with an HTML page,
+// the others with a JSON {"error": ...} body.
+type Error struct {
+	Status   int    `json:"status" yaml:"status"`
+	Error    string `json:"error" yaml:"error"`
+	Balancer bool   `json:"balancer" yaml:"balancer"`
+}
+
+// Load reads a definition from a JSON or YAML file.
+func Load(path string) (Definition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Definition{}, fmt.Errorf("%w", err)
+	}
+
+	return Parse(data)
+}
+
+// Parse decodes a JSON or YAML definition.
+func Parse(data []byte) (Definition, error) {
+	var def Definition
+
+	if err := yaml.Unmarshal(data, &def); err != nil {
+		return Definition{}, fmt.Errorf("%w", err)
+	}
+
+	return def, nil
+}
+
+func (r Result) builderData() builder.ResponseData {
+	return builder.ResponseData{
+		Description: r.Data.Description,
+		ErrorCode:   r.Data.ErrorCode,
+		Vars:        r.Data.Vars,
+	}
+}
+
+// normalize converts a value to the types encoding/json decodes into, so
+// values read from YAML compare with the parameters of requests.
+func normalize(value interface{}) interface{} {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+
+	var normalized interface{}
+	if err := json.Unmarshal(content, &normalized); err != nil {
+		return value
+	}
+
+	return normalized
+}
+
+// matches reports whether every key of match has the same value in params.
+func matches(match, params map[string]interface{}) bool {
+	for key, want := range match {
+		got, ok := params[key]
+		if !ok || !reflect.DeepEqual(normalize(want), normalize(got)) {
+			return false
+		}
+	}
+
+	return true
+}
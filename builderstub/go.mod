@@ -0,0 +1,9 @@
+module github.com/reevolute/builder-go/builderstub
+
+go 1.17
+
+require (
+	github.com/google/go-cmp v0.5.9
+	github.com/reevolute/builder-go v0.3.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,299 @@
+// Package builderstub is a local stand-in of the Builder v2 HTTP API serving
+// scripted trees, for integration tests and local development.
+//
+//	def, err := builderstub.Load("trees.yaml")
+//	server := httptest.NewServer(builderstub.NewServer(def))
+//	client := builder.New("key", def.Tenant, builder.WithBaseURL(server.URL))
+package builderstub
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	headerSessionID = "X-Session-Id"
+	headerRequestID = "X-Request-Id"
+	headerTraceID   = "X-Trace-Id"
+
+	balancerPage = "<html>\r\n<head><title>%d %s</title></head>\r\n" +
+		"<body>\r\n<center><h1>%d %s</h1></center>\r\n</body>\r\n</html>\r\n"
+)
+
+type session struct {
+	tree      *Tree
+	execution *Execution
+	step      int
+	last      Result
+}
+
+// Server serves a Definition, it is an http.Handler safe for concurrent use.
+type Server struct {
+	def Definition
+
+	mu          sync.Mutex
+	sessions    map[string]*session
+	unavailable bool
+}
+
+// NewServer creates a Server for def.
+func NewServer(def Definition) *Server {
+	return &Server{
+		def:      def,
+		sessions: make(map[string]*session),
+	}
+}
+
+// SetUnavailable makes every request fail with the balancer 503 page, the
+// rate limit of Builder.
+func (s *Server) SetUnavailable(unavailable bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.unavailable = unavailable
+}
+
+type requestBody struct {
+	Parameters      map[string]interface{} `json:"parameters"`
+	InteractionType string                 `json:"type"`
+}
+
+// ServeHTTP routes the request to the Builder v2 endpoints.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body requestBody
+
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, &Error{Status: http.StatusBadRequest, Error: "invalid_body"})
+
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set(headerTraceID, newID())
+
+	if s.unavailable {
+		writeError(w, &Error{Status: http.StatusServiceUnavailable, Balancer: true})
+
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 4 || parts[0] != "v2" || parts[1] != "tenants" || parts[2] != s.def.Tenant {
+		writeError(w, &Error{Status: http.StatusNotFound, Balancer: true})
+
+		return
+	}
+
+	if err := s.authorize(r); err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	switch route := parts[3:]; {
+	case len(route) == 5 && route[0] == "trees" && route[2] == "releases" && route[4] == "executions" &&
+		r.Method == http.MethodPost:
+		s.execute(w, body, route[1], route[3])
+	case len(route) == 3 && route[0] == "executions" && route[2] == "interactions" && r.Method == http.MethodPost:
+		s.interact(w, body, route[1])
+	case len(route) == 2 && route[0] == "executions" && r.Method == http.MethodGet:
+		s.sessionInformation(w, route[1])
+	default:
+		writeError(w, &Error{Status: http.StatusNotFound, Error: "not_found"})
+	}
+}
+
+func (s *Server) authorize(r *http.Request) *Error {
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return &Error{Status: http.StatusBadRequest, Error: "authorization header format must be Bearer {token}"}
+	}
+
+	if s.def.APIKey != "" && strings.TrimPrefix(authorization, "Bearer ") != s.def.APIKey {
+		return &Error{Status: http.StatusUnauthorized, Error: "invalidApiKey"}
+	}
+
+	return nil
+}
+
+func (s *Server) findTree(treeID, releaseID string) (*Tree, *Error) {
+	for i := range s.def.Trees {
+		tree := &s.def.Trees[i]
+		if tree.ID != treeID {
+			continue
+		}
+
+		if len(tree.Releases) == 0 {
+			return tree, nil
+		}
+
+		for _, release := range tree.Releases {
+			if release == releaseID {
+				return tree, nil
+			}
+		}
+
+		return nil, &Error{Status: http.StatusNotFound, Error: "function_not_found"}
+	}
+
+	return nil, &Error{Status: http.StatusNotFound, Error: "tree_not_found"}
+}
+
+func (s *Server) execute(w http.ResponseWriter, body requestBody, treeID, releaseID string) {
+	tree, stubErr := s.findTree(treeID, releaseID)
+	if stubErr != nil {
+		writeError(w, stubErr)
+
+		return
+	}
+
+	var execution *Execution
+
+	for i := range tree.Executions {
+		if matches(tree.Executions[i].Match, body.Parameters) {
+			execution = &tree.Executions[i]
+
+			break
+		}
+	}
+
+	if execution == nil {
+		writeError(w, &Error{Status: http.StatusInternalServerError, Error: "no_matching_execution"})
+
+		return
+	}
+
+	if execution.Error != nil {
+		writeError(w, execution.Error)
+
+		return
+	}
+
+	sessionID := newID()
+	requestID := newID()
+
+	s.sessions[sessionID] = &session{
+		tree:      tree,
+		execution: execution,
+		last:      execution.Response,
+	}
+
+	w.Header().Set(headerSessionID, sessionID)
+	w.Header().Set(headerRequestID, requestID)
+
+	if body.InteractionType == "async" {
+		w.WriteHeader(http.StatusCreated)
+
+		return
+	}
+
+	writeResult(w, tree, execution.Response)
+}
+
+func (s *Server) interact(w http.ResponseWriter, body requestBody, sessionID string) {
+	sess, ok := s.sessions[sessionID]
+	if !ok {
+		writeError(w, &Error{Status: http.StatusNotFound, Error: "session_not_found"})
+
+		return
+	}
+
+	if sess.step >= len(sess.execution.Steps) {
+		writeError(w, &Error{Status: http.StatusBadRequest, Error: "session_finished"})
+
+		return
+	}
+
+	step := sess.execution.Steps[sess.step]
+
+	if (step.Type != "" && step.Type != body.InteractionType) || !matches(step.Match, body.Parameters) {
+		writeError(w, &Error{Status: http.StatusBadRequest, Error: "unexpected_interaction"})
+
+		return
+	}
+
+	if step.Error != nil {
+		writeError(w, step.Error)
+
+		return
+	}
+
+	sess.step++
+	sess.last = step.Response
+
+	w.Header().Set(headerSessionID, sessionID)
+	w.Header().Set(headerRequestID, newID())
+	writeResult(w, sess.tree, step.Response)
+}
+
+func (s *Server) sessionInformation(w http.ResponseWriter, sessionID string) {
+	sess, ok := s.sessions[sessionID]
+	if !ok {
+		writeError(w, &Error{Status: http.StatusNotFound, Error: "session_not_found"})
+
+		return
+	}
+
+	w.Header().Set(headerSessionID, sessionID)
+	w.Header().Set(headerRequestID, newID())
+	writeResult(w, sess.tree, sess.last)
+}
+
+func writeResult(w http.ResponseWriter, tree *Tree, result Result) {
+	body := struct {
+		TreeVersion  string      `json:"tree_version"`
+		ResponseType string      `json:"response_type"`
+		Data         interface{} `json:"data"`
+	}{
+		TreeVersion:  tree.Version,
+		ResponseType: result.ResponseType,
+		Data:         result.builderData(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// writeError answers like Builder does: balancer errors with an HTML page,
+// the others with a JSON {"error": ...} body.
+func writeError(w http.ResponseWriter, stubErr *Error) {
+	status := stubErr.Status
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+
+	if stubErr.Balancer {
+		text := http.StatusText(status)
+
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, balancerPage, status, text, status, text)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": stubErr.Error})
+}
+
+func newID() string {
+	id := make([]byte, 16)
+
+	if _, err := rand.Read(id); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(id)
+}
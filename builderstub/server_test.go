@@ -0,0 +1,180 @@
+package builderstub
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/reevolute/builder-go"
+)
+
+func newTestServer(t *testing.T) (*Server, *httptest.Server) {
+	t.Helper()
+
+	def, err := Load("testdata/trees.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stub := NewServer(def)
+
+	return stub, httptest.NewServer(stub)
+}
+
+func TestServerExecution(t *testing.T) {
+	_, server := newTestServer(t)
+	defer server.Close()
+
+	client := builder.New("aabbcc", "my_tenant_1312", builder.WithBaseURL(server.URL))
+
+	response, err := client.AddExecution("color_pick", "production", map[string]interface{}{"color": "red"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.SessionID == "" || response.RequestID == "" {
+		t.Errorf("want session and request ids got [%s] [%s]", response.SessionID, response.RequestID)
+	}
+
+	want := builder.ResponseData{
+		Description: "function evaluation",
+		ErrorCode:   "0",
+		Vars: map[string]interface{}{
+			"child_response":  "red",
+			"concat_response": "COLOR: rojo",
+		},
+	}
+
+	if diff := cmp.Diff(want, response.Data); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	if response.TreeVersion != "3" {
+		t.Errorf("want tree version [3] got [%s]", response.TreeVersion)
+	}
+
+	info, err := client.GetSessionInformation(response.SessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(want, info.Data); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestServerAsyncExecution(t *testing.T) {
+	_, server := newTestServer(t)
+	defer server.Close()
+
+	client := builder.New("aabbcc", "my_tenant_1312", builder.WithBaseURL(server.URL))
+
+	requestID, err := client.AddAsyncExecution("color_pick", "production", map[string]interface{}{"color": "blue"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if requestID == "" {
+		t.Error("want a request ID")
+	}
+}
+
+func TestServerSession(t *testing.T) {
+	_, server := newTestServer(t)
+	defer server.Close()
+
+	client := builder.New("aabbcc", "my_tenant_1312", builder.WithBaseURL(server.URL))
+
+	flow := builder.NewFlow().HandleVar("size",
+		func(ctx context.Context, name string, response builder.Response) (interface{}, error) {
+			return "L", nil
+		})
+
+	transcript, err := flow.Run(context.Background(), client, "color_pick", "production", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if transcript.Response.Data.Vars["size"] != float64(42) {
+		t.Errorf("want [42] got [%v]", transcript.Response.Data.Vars["size"])
+	}
+
+	_, err = client.AddInteraction(transcript.SessionID, builder.InteractionTypeContinue, nil)
+	if !errors.Is(err, builder.ErrBuilderAPI) {
+		t.Errorf("want [%v] got [%v]", builder.ErrBuilderAPI, err)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	stub, server := newTestServer(t)
+	defer server.Close()
+
+	cases := []struct {
+		name   string
+		key    string
+		tenant string
+		tree   string
+		params map[string]interface{}
+		want   error
+	}{
+		{"tenant not found", "aabbcc", "other", "color_pick", nil, builder.ErrTenantNotFound},
+		{"invalid key", "wrong", "my_tenant_1312", "color_pick", nil, builder.ErrInvalidAPIKey},
+		{"api key format", "", "my_tenant_1312", "color_pick", nil, builder.ErrAPIKeyFormat},
+		{"tree not found", "aabbcc", "my_tenant_1312", "unknown", nil, builder.ErrTreeNotFound},
+		{"rate limit", "aabbcc", "my_tenant_1312", "color_pick", map[string]interface{}{"color": "green"},
+			builder.ErrRateLimit},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			client := builder.New(tt.key, tt.tenant, builder.WithBaseURL(server.URL))
+
+			_, err := client.AddExecution(tt.tree, "production", tt.params)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("want [%v] got [%v]", tt.want, err)
+			}
+
+			var apiErr *builder.APIError
+			if errors.As(err, &apiErr) && apiErr.TraceID == "" {
+				t.Error("want a trace id")
+			}
+		})
+	}
+
+	client := builder.New("aabbcc", "my_tenant_1312", builder.WithBaseURL(server.URL))
+
+	_, err := client.AddExecution("color_pick", "staging", nil)
+	if !errors.Is(err, builder.ErrReleaseNotFound) {
+		t.Errorf("want [%v] got [%v]", builder.ErrReleaseNotFound, err)
+	}
+
+	stub.SetUnavailable(true)
+
+	_, err = client.GetSessionInformation("c563cd9a979c46c18d8d892b122f5e38")
+	if !errors.Is(err, builder.ErrRateLimit) {
+		t.Errorf("want [%v] got [%v]", builder.ErrRateLimit, err)
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	def, err := Load("testdata/trees.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(NewServer(def))
+	defer server.Close()
+
+	client := builder.New("any", "my_tenant_1312", builder.WithBaseURL(server.URL))
+
+	response, err := client.AddExecution("color_pick", "any_release", map[string]interface{}{"color": "red"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Data.Vars["child_response"] != "red" {
+		t.Errorf("want [red] got [%v]", response.Data.Vars["child_response"])
+	}
+}
@@ -0,0 +1,383 @@
+// Package buildertest provides a programmable in-memory implementation of
+// builder.Client for tests.
+package buildertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/reevolute/builder-go"
+)
+
+// Methods of the client, as recorded in Call.
+const (
+	MethodAddExecution          = "AddExecution"
+	MethodAddAsyncExecution     = "AddAsyncExecution"
+	MethodAddInteraction        = "AddInteraction"
+	MethodGetSessionInformation = "GetSessionInformation"
+)
+
+// ErrUnexpectedCall is returned for calls without a matching expectation.
+var ErrUnexpectedCall = errors.New("unexpected_call")
+
+// Call is a call received by the Fake.
+type Call struct {
+	Method          string
+	TreeID          string
+	ReleaseID       string
+	SessionID       string
+	InteractionType string
+	Params          map[string]interface{}
+}
+
+type result struct {
+	response builder.Response
+	err      error
+}
+
+// Expectation scripts the results of the calls matching it.
+type Expectation struct {
+	fake *Fake
+
+	method          string
+	treeID          string
+	releaseID       string
+	sessionID       string
+	interactionType string
+	params          map[string]interface{}
+	matchParams     bool
+
+	results []result
+	times   int
+	calls   int
+}
+
+// WithParams restricts the expectation to calls with params.
+func (e *Expectation) WithParams(params map[string]interface{}) *Expectation {
+	e.fake.mu.Lock()
+	defer e.fake.mu.Unlock()
+
+	e.params = params
+	e.matchParams = true
+
+	return e
+}
+
+// Return appends a response to the script, the last result is repeated once
+// the script is exhausted.
+func (e *Expectation) Return(response builder.Response) *Expectation {
+	e.fake.mu.Lock()
+	defer e.fake.mu.Unlock()
+
+	e.results = append(e.results, result{response: response})
+
+	return e
+}
+
+// ReturnError appends an error to the script, see APIError to build the
+// errors returned by Builder.
+func (e *Expectation) ReturnError(err error) *Expectation {
+	e.fake.mu.Lock()
+	defer e.fake.mu.Unlock()
+
+	e.results = append(e.results, result{err: err})
+
+	return e
+}
+
+// Times sets the number of calls the expectation accepts, by default it
+// accepts any number of calls but at least one.
+func (e *Expectation) Times(n int) *Expectation {
+	e.fake.mu.Lock()
+	defer e.fake.mu.Unlock()
+
+	e.times = n
+
+	return e
+}
+
+// Once is Times(1).
+func (e *Expectation) Once() *Expectation {
+	return e.Times(1)
+}
+
+func (e *Expectation) String() string {
+	switch e.method {
+	case MethodAddInteraction:
+		return fmt.Sprintf("%s(%s, %s)", e.method, e.sessionID, e.interactionType)
+	case MethodGetSessionInformation:
+		return fmt.Sprintf("%s(%s)", e.method, e.sessionID)
+	}
+
+	return fmt.Sprintf("%s(%s, %s)", e.method, e.treeID, e.releaseID)
+}
+
+func (e *Expectation) matches(call Call) bool {
+	if e.method != call.Method || e.treeID != call.TreeID || e.releaseID != call.ReleaseID ||
+		e.sessionID != call.SessionID || e.interactionType != call.InteractionType {
+		return false
+	}
+
+	if e.matchParams && !reflect.DeepEqual(e.params, call.Params) {
+		return false
+	}
+
+	return e.times == 0 || e.calls < e.times
+}
+
+func (e *Expectation) next() result {
+	e.calls++
+
+	if len(e.results) == 0 {
+		return result{}
+	}
+
+	if e.calls > len(e.results) {
+		return e.results[len(e.results)-1]
+	}
+
+	return e.results[e.calls-1]
+}
+
+// Fake is an in-memory builder.Client, safe for concurrent use. Calls are
+// matched against the expectations in the order they were added.
+type Fake struct {
+	mu           sync.Mutex
+	expectations []*Expectation
+	calls        []Call
+	sequence     int
+}
+
+var (
+	_ builder.Client        = (*Fake)(nil)
+	_ builder.ContextClient = (*Fake)(nil)
+)
+
+// New creates a Fake without expectations.
+func New() *Fake {
+	return &Fake{}
+}
+
+func (f *Fake) expect(e *Expectation) *Expectation {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	e.fake = f
+	f.expectations = append(f.expectations, e)
+
+	return e
+}
+
+// ExpectExecution adds an expectation for sync executions of the tree release.
+func (f *Fake) ExpectExecution(treeID, releaseID string) *Expectation {
+	return f.expect(&Expectation{method: MethodAddExecution, treeID: treeID, releaseID: releaseID})
+}
+
+// ExpectAsyncExecution adds an expectation for async executions of the tree
+// release, the RequestID of the scripted response is returned.
+func (f *Fake) ExpectAsyncExecution(treeID, releaseID string) *Expectation {
+	return f.expect(&Expectation{method: MethodAddAsyncExecution, treeID: treeID, releaseID: releaseID})
+}
+
+// ExpectInteraction adds an expectation for interactions of the session.
+func (f *Fake) ExpectInteraction(sessionID, interactionType string) *Expectation {
+	return f.expect(&Expectation{
+		method:          MethodAddInteraction,
+		sessionID:       sessionID,
+		interactionType: interactionType,
+	})
+}
+
+// ExpectSessionInformation adds an expectation for lookups of the session.
+func (f *Fake) ExpectSessionInformation(sessionID string) *Expectation {
+	return f.expect(&Expectation{method: MethodGetSessionInformation, sessionID: sessionID})
+}
+
+// Calls returns the calls received by the fake.
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	calls := make([]Call, len(f.calls))
+	copy(calls, f.calls)
+
+	return calls
+}
+
+// AssertExpectations fails t when an expectation was not called the expected
+// number of times.
+func (f *Fake) AssertExpectations(t testing.TB) {
+	t.Helper()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, e := range f.expectations {
+		switch {
+		case e.times == 0 && e.calls == 0:
+			t.Errorf("expected call %s was not made", e)
+		case e.times > 0 && e.calls != e.times:
+			t.Errorf("expected call %s [%d] times got [%d]", e, e.times, e.calls)
+		}
+	}
+}
+
+// AssertNumberOfCalls fails t when method was not called n times.
+func (f *Fake) AssertNumberOfCalls(t testing.TB, method string, n int) {
+	t.Helper()
+
+	got := 0
+
+	for _, call := range f.Calls() {
+		if call.Method == method {
+			got++
+		}
+	}
+
+	if got != n {
+		t.Errorf("expected %s to be called [%d] times got [%d]", method, n, got)
+	}
+}
+
+func (f *Fake) call(ctx context.Context, call Call) (builder.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return builder.Response{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, call)
+	f.sequence++
+
+	for _, e := range f.expectations {
+		if !e.matches(call) {
+			continue
+		}
+
+		res := e.next()
+		if res.err != nil {
+			return builder.Response{}, res.err
+		}
+
+		response := res.response
+
+		if response.RequestID == "" {
+			response.RequestID = fmt.Sprintf("request-%d", f.sequence)
+		}
+
+		if response.SessionID == "" {
+			response.SessionID = call.SessionID
+		}
+
+		if response.SessionID == "" && call.Method == MethodAddExecution {
+			response.SessionID = fmt.Sprintf("session-%d", f.sequence)
+		}
+
+		return response, nil
+	}
+
+	return builder.Response{}, fmt.Errorf("%w: %s %+v", ErrUnexpectedCall, call.Method, call)
+}
+
+// AddExecution implements builder.Client.
+func (f *Fake) AddExecution(treeID, releaseID string, params map[string]interface{}) (builder.Response, error) {
+	return f.AddExecutionContext(context.Background(), treeID, releaseID, params)
+}
+
+// AddAsyncExecution implements builder.Client.
+func (f *Fake) AddAsyncExecution(treeID, releaseID string, params map[string]interface{}) (string, error) {
+	return f.AddAsyncExecutionContext(context.Background(), treeID, releaseID, params)
+}
+
+// AddInteraction implements builder.Client.
+func (f *Fake) AddInteraction(sessionID, interactionType string,
+	params map[string]interface{}) (builder.Response, error) {
+	return f.AddInteractionContext(context.Background(), sessionID, interactionType, params)
+}
+
+// GetSessionInformation implements builder.Client.
+func (f *Fake) GetSessionInformation(sessionID string) (builder.Response, error) {
+	return f.GetSessionInformationContext(context.Background(), sessionID)
+}
+
+// AddExecutionContext implements builder.ContextClient.
+func (f *Fake) AddExecutionContext(ctx context.Context, treeID, releaseID string,
+	params map[string]interface{}) (builder.Response, error) {
+	return f.call(ctx, Call{
+		Method:    MethodAddExecution,
+		TreeID:    treeID,
+		ReleaseID: releaseID,
+		Params:    params,
+	})
+}
+
+// AddAsyncExecutionContext implements builder.ContextClient.
+func (f *Fake) AddAsyncExecutionContext(ctx context.Context, treeID, releaseID string,
+	params map[string]interface{}) (string, error) {
+	response, err := f.call(ctx, Call{
+		Method:    MethodAddAsyncExecution,
+		TreeID:    treeID,
+		ReleaseID: releaseID,
+		Params:    params,
+	})
+
+	return response.RequestID, err
+}
+
+// AddInteractionContext implements builder.ContextClient.
+func (f *Fake) AddInteractionContext(ctx context.Context, sessionID, interactionType string,
+	params map[string]interface{}) (builder.Response, error) {
+	return f.call(ctx, Call{
+		Method:          MethodAddInteraction,
+		SessionID:       sessionID,
+		InteractionType: interactionType,
+		Params:          params,
+	})
+}
+
+// GetSessionInformationContext implements builder.ContextClient.
+func (f *Fake) GetSessionInformationContext(ctx context.Context, sessionID string) (builder.Response, error) {
+	return f.call(ctx, Call{
+		Method:    MethodGetSessionInformation,
+		SessionID: sessionID,
+	})
+}
+
+// APIError returns the *builder.APIError Builder answers with for one of the
+// builder Err sentinels.
+func APIError(err error) *builder.APIError {
+	apiErr := &builder.APIError{
+		StatusCode: http.StatusInternalServerError,
+		Code:       err.Error(),
+		Err:        err,
+	}
+
+	switch err {
+	case builder.ErrTreeNotFound:
+		apiErr.StatusCode = http.StatusNotFound
+	case builder.ErrReleaseNotFound:
+		apiErr.StatusCode = http.StatusNotFound
+		apiErr.Code = "function_not_found"
+	case builder.ErrTenantNotFound:
+		apiErr.StatusCode = http.StatusNotFound
+		apiErr.Code = ""
+	case builder.ErrInvalidAPIKey:
+		apiErr.StatusCode = http.StatusUnauthorized
+	case builder.ErrAPIKeyFormat:
+		apiErr.StatusCode = http.StatusBadRequest
+		apiErr.Code = "authorization header format must be Bearer {token}"
+	case builder.ErrPermissions:
+		apiErr.StatusCode = http.StatusForbidden
+	case builder.ErrRateLimit:
+		apiErr.StatusCode = http.StatusServiceUnavailable
+		apiErr.Code = ""
+	}
+
+	return apiErr
+}
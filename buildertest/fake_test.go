@@ -0,0 +1,123 @@
+package buildertest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/reevolute/builder-go"
+)
+
+func TestFakeScript(t *testing.T) {
+	fake := New()
+
+	fake.ExpectExecution("color_pick", "production").
+		WithParams(map[string]interface{}{"color": "red"}).
+		Return(builder.Response{ResponseType: "INPUT", SessionID: "s1"}).
+		Once()
+	fake.ExpectExecution("color_pick", "production").
+		ReturnError(APIError(builder.ErrRateLimit)).
+		Return(builder.Response{ResponseType: "COMMON"})
+	fake.ExpectInteraction("s1", builder.InteractionTypeContinue).
+		Return(builder.Response{ResponseType: "COMMON"})
+
+	session, err := builder.StartSession(context.Background(), fake, "color_pick", "production",
+		map[string]interface{}{"color": "red"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := session.Continue(context.Background(), builder.InteractionTypeContinue, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.SessionID != "s1" || !session.Done() {
+		t.Errorf("want session [s1] done got [%s] [%v]", response.SessionID, session.Done())
+	}
+
+	_, err = fake.AddExecution("color_pick", "production", map[string]interface{}{"color": "blue"})
+	if !builder.IsRateLimited(err) {
+		t.Errorf("want [%v] got [%v]", builder.ErrRateLimit, err)
+	}
+
+	for i := 0; i < 2; i++ {
+		response, err = fake.AddExecution("color_pick", "production", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if response.ResponseType != "COMMON" {
+			t.Errorf("the last result must be repeated, got [%s]", response.ResponseType)
+		}
+	}
+
+	_, err = fake.GetSessionInformation("s2")
+	if !errors.Is(err, ErrUnexpectedCall) {
+		t.Errorf("want [%v] got [%v]", ErrUnexpectedCall, err)
+	}
+
+	fake.AssertExpectations(t)
+	fake.AssertNumberOfCalls(t, MethodAddExecution, 4)
+	fake.AssertNumberOfCalls(t, MethodGetSessionInformation, 1)
+
+	if calls := fake.Calls(); calls[1].Method != MethodAddInteraction || calls[1].SessionID != "s1" {
+		t.Errorf("want interaction on [s1] got [%+v]", calls[1])
+	}
+}
+
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, format)
+}
+
+func TestFakeAssertExpectations(t *testing.T) {
+	fake := New()
+
+	fake.ExpectSessionInformation("s1")
+	fake.ExpectAsyncExecution("color_pick", "production").Times(2)
+
+	requestID, err := fake.AddAsyncExecution("color_pick", "production", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if requestID == "" {
+		t.Error("want a generated request id")
+	}
+
+	rec := &recorder{TB: t}
+	fake.AssertExpectations(rec)
+
+	if len(rec.errors) != 2 {
+		t.Errorf("want [2] failed expectations got [%d]", len(rec.errors))
+	}
+}
+
+func TestFakeConcurrent(t *testing.T) {
+	fake := New()
+	fake.ExpectExecution("color_pick", "production").Return(builder.Response{ResponseType: "COMMON"})
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if _, err := fake.AddExecution("color_pick", "production", nil); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	fake.AssertNumberOfCalls(t, MethodAddExecution, 20)
+}
@@ -0,0 +1,392 @@
+// Package bulk runs executions of a tree release for every row of a CSV or
+// JSONL input and writes the results to a CSV or JSONL output. Runs can be
+// resumed from a checkpoint file after an interruption.
+package bulk
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/reevolute/builder-go"
+)
+
+// defaultConcurrency is the number of executions run at the same time.
+const defaultConcurrency = 4
+
+// Columns written before the vars in the output.
+var resultColumns = []string{"row", "status", "error_code", "error", "session_id", "request_id",
+	"tree_version", "response_type"}
+
+// Config configures a bulk run.
+type Config struct {
+	TreeID    string
+	ReleaseID string
+
+	// InputFormat and OutputFormat are FormatCSV or FormatJSONL.
+	InputFormat  string
+	OutputFormat string
+
+	// Mapping maps input columns to params, every column is passed as is when empty.
+	Mapping map[string]Param
+
+	// Vars are the vars written as CSV columns, flattened with dots for nested
+	// values. When empty all the vars are written as JSON in a vars column.
+	// JSONL outputs always contain all the flattened vars.
+	Vars []string
+
+	// Concurrency is the number of executions run at the same time, 4 by
+	// default. The executions per second are limited by the client, see
+	// builder.WithTreeRateLimit.
+	Concurrency int
+
+	// Checkpoint is the file recording the rows already processed, rows
+	// found in it are skipped. The output of a resumed run must be appended
+	// to the output of the interrupted one.
+	Checkpoint string
+}
+
+// Stats summarizes a run.
+type Stats struct {
+	Rows      int
+	Skipped   int
+	Succeeded int
+	Failed    int
+}
+
+type result struct {
+	row      int
+	response builder.Response
+	err      error
+}
+
+// Run executes the tree release for every row of in and writes the results
+// to out. Rows failing are written with their error code, Run only returns
+// errors reading the input or writing the output. When ctx is done, Run stops
+// and the rows not written are processed by the next run.
+func Run(ctx context.Context, client builder.ContextClient, in io.Reader, out io.Writer,
+	cfg Config) (Stats, error) {
+	done, err := loadCheckpoint(cfg.Checkpoint)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	var checkpoint *os.File
+
+	if cfg.Checkpoint != "" {
+		checkpoint, err = os.OpenFile(cfg.Checkpoint, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return Stats{}, fmt.Errorf("%w", err)
+		}
+
+		defer checkpoint.Close()
+	}
+
+	writer := newWriter(out, cfg.OutputFormat, cfg.Vars)
+
+	if len(done) == 0 {
+		if err := writer.header(); err != nil {
+			return Stats{}, err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	rows := make(chan row)
+	results := make(chan result)
+
+	readErr := make(chan error, 1)
+
+	go func() {
+		defer close(rows)
+
+		readErr <- readRows(in, cfg.InputFormat, rows, ctx.Done())
+	}()
+
+	var (
+		stats   Stats
+		statsMu sync.Mutex
+		wg      sync.WaitGroup
+	)
+
+	// skip counts the row and reports whether it was processed by a previous run.
+	skip := func(r row) bool {
+		statsMu.Lock()
+		defer statsMu.Unlock()
+
+		stats.Rows++
+
+		if done[r.index] {
+			stats.Skipped++
+
+			return true
+		}
+
+		return false
+	}
+
+	concurrency := cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for r := range rows {
+				if skip(r) {
+					continue
+				}
+
+				res := execute(ctx, client, cfg, r)
+				if ctx.Err() != nil {
+					return
+				}
+
+				results <- res
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var writeErr error
+
+	for res := range results {
+		if writeErr != nil {
+			continue
+		}
+
+		if writeErr = writer.write(res); writeErr == nil && checkpoint != nil {
+			_, writeErr = fmt.Fprintln(checkpoint, res.row)
+		}
+
+		if writeErr != nil {
+			cancel()
+
+			continue
+		}
+
+		if res.err != nil {
+			stats.Failed++
+		} else {
+			stats.Succeeded++
+		}
+	}
+
+	if writeErr != nil {
+		return stats, writeErr
+	}
+
+	select {
+	case err := <-readErr:
+		if err != nil {
+			return stats, fmt.Errorf("%w", err)
+		}
+	case <-ctx.Done():
+	}
+
+	return stats, ctx.Err()
+}
+
+func execute(ctx context.Context, client builder.ContextClient, cfg Config, r row) result {
+	if r.err != nil {
+		return result{row: r.index, err: r.err}
+	}
+
+	p, err := params(r.values, cfg.Mapping)
+	if err != nil {
+		return result{row: r.index, err: err}
+	}
+
+	response, err := client.AddExecutionContext(ctx, cfg.TreeID, cfg.ReleaseID, p)
+
+	return result{row: r.index, response: response, err: err}
+}
+
+func loadCheckpoint(path string) (map[int]bool, error) {
+	done := make(map[int]bool)
+
+	if path == "" {
+		return done, nil
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return done, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		index, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+		if err == nil {
+			done[index] = true
+		}
+	}
+
+	return done, scanner.Err()
+}
+
+// errorCode returns the code of the Builder error behind err, such as
+// tree_not_found, or invalid_row and error.
+func errorCode(err error) string {
+	var apiErr *builder.APIError
+
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr.Err.Error()
+	case errors.Is(err, ErrInvalidRow):
+		return ErrInvalidRow.Error()
+	}
+
+	return "error"
+}
+
+// flatten adds the vars to dst, nested maps are joined with dots.
+func flatten(prefix string, vars map[string]interface{}, dst map[string]interface{}) {
+	for key, value := range vars {
+		if nested, ok := value.(map[string]interface{}); ok {
+			flatten(prefix+key+".", nested, dst)
+
+			continue
+		}
+
+		dst[prefix+key] = value
+	}
+}
+
+type writer struct {
+	format string
+	vars   []string
+	csv    *csv.Writer
+	json   *json.Encoder
+}
+
+func newWriter(out io.Writer, format string, vars []string) *writer {
+	if format == FormatJSONL {
+		return &writer{format: format, json: json.NewEncoder(out)}
+	}
+
+	return &writer{format: format, vars: vars, csv: csv.NewWriter(out)}
+}
+
+func (w *writer) header() error {
+	if w.format == FormatJSONL {
+		return nil
+	}
+
+	header := append([]string{}, resultColumns...)
+
+	if len(w.vars) == 0 {
+		header = append(header, "vars")
+	} else {
+		header = append(header, w.vars...)
+	}
+
+	return w.flush(header)
+}
+
+func (w *writer) write(res result) error {
+	status, code, message := "ok", "", ""
+	if res.err != nil {
+		status, code, message = "error", errorCode(res.err), res.err.Error()
+	}
+
+	vars := make(map[string]interface{})
+	flatten("", res.response.Data.Vars, vars)
+
+	if w.format == FormatJSONL {
+		record := map[string]interface{}{
+			"row":           res.row,
+			"status":        status,
+			"error_code":    code,
+			"error":         message,
+			"session_id":    res.response.SessionID,
+			"request_id":    res.response.RequestID,
+			"tree_version":  res.response.TreeVersion,
+			"response_type": res.response.ResponseType,
+			"vars":          vars,
+		}
+
+		if err := w.json.Encode(record); err != nil {
+			return fmt.Errorf("%w", err)
+		}
+
+		return nil
+	}
+
+	record := []string{strconv.Itoa(res.row), status, code, message, res.response.SessionID,
+		res.response.RequestID, res.response.TreeVersion, res.response.ResponseType}
+
+	if len(w.vars) == 0 {
+		content := ""
+
+		if len(vars) > 0 {
+			encoded, err := json.Marshal(vars)
+			if err != nil {
+				return fmt.Errorf("%w", err)
+			}
+
+			content = string(encoded)
+		}
+
+		record = append(record, content)
+	}
+
+	for _, name := range w.vars {
+		record = append(record, formatValue(vars[name]))
+	}
+
+	return w.flush(record)
+}
+
+func (w *writer) flush(record []string) error {
+	if err := w.csv.Write(record); err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	w.csv.Flush()
+
+	if err := w.csv.Error(); err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	return nil
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+
+	content, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	return string(content)
+}
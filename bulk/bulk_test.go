@@ -0,0 +1,184 @@
+package bulk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/reevolute/builder-go"
+	"github.com/reevolute/builder-go/buildertest"
+)
+
+func newFake() *buildertest.Fake {
+	fake := buildertest.New()
+
+	fake.ExpectExecution("scoring", "production").
+		WithParams(map[string]interface{}{"name": "Ana", "age": int64(31), "vip": true}).
+		Return(builder.Response{
+			SessionID:    "s1",
+			RequestID:    "r1",
+			TreeVersion:  "3",
+			ResponseType: "COMMON",
+			Data: builder.ResponseData{Vars: map[string]interface{}{
+				"score":  float64(720),
+				"detail": map[string]interface{}{"band": "A"},
+			}},
+		})
+	fake.ExpectExecution("scoring", "production").
+		ReturnError(buildertest.APIError(builder.ErrTreeNotFound))
+
+	return fake
+}
+
+func TestRunCSV(t *testing.T) {
+	input := "name,age,vip\nAna,31,true\nLuis,x,false\nBea,40,false\n"
+
+	var out bytes.Buffer
+
+	stats, err := Run(context.Background(), newFake(), strings.NewReader(input), &out, Config{
+		TreeID:       "scoring",
+		ReleaseID:    "production",
+		InputFormat:  FormatCSV,
+		OutputFormat: FormatCSV,
+		Mapping: map[string]Param{
+			"name": {},
+			"age":  {Type: TypeInt},
+			"vip":  {Type: TypeBool},
+		},
+		Vars:        []string{"score", "detail.band"},
+		Concurrency: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(Stats{Rows: 3, Succeeded: 1, Failed: 2}, stats); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+
+	want := []string{
+		"row,status,error_code,error,session_id,request_id,tree_version,response_type,score,detail.band",
+		"0,ok,,,s1,r1,3,COMMON,720,A",
+		`1,error,invalid_row,"invalid_row: column age: strconv.ParseInt: parsing ""x"": invalid syntax",,,,,,`,
+		"2,error,tree_not_found,tree_not_found,,,,,,",
+	}
+
+	if diff := cmp.Diff(want, lines); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunJSONLResume(t *testing.T) {
+	input := `{"name": "Ana", "age": 31, "vip": true}
+{"name": "Bea", "age": 40, "vip": false}
+`
+
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint")
+
+	cfg := Config{
+		TreeID:       "scoring",
+		ReleaseID:    "production",
+		InputFormat:  FormatJSONL,
+		OutputFormat: FormatJSONL,
+		Mapping: map[string]Param{
+			"name": {},
+			"age":  {Type: TypeInt},
+			"vip":  {},
+		},
+		Concurrency: 1,
+		Checkpoint:  checkpoint,
+	}
+
+	var out bytes.Buffer
+
+	if _, err := Run(context.Background(), newFake(), strings.NewReader(input), &out, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	var first map[string]interface{}
+	if err := json.Unmarshal([]byte(strings.Split(out.String(), "\n")[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+
+	vars, _ := first["vars"].(map[string]interface{})
+	if first["row"] != float64(0) || vars["detail.band"] != "A" {
+		t.Errorf("want row [0] with flattened vars got [%v]", first)
+	}
+
+	fake := newFake()
+
+	stats, err := Run(context.Background(), fake, strings.NewReader(input+`{"name": "Ana", "age": 31, "vip": true}`),
+		&out, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(Stats{Rows: 3, Skipped: 2, Succeeded: 1}, stats); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	fake.AssertNumberOfCalls(t, buildertest.MethodAddExecution, 1)
+
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 3 {
+		t.Errorf("want [3] output lines got [%d]", len(lines))
+	}
+}
+
+func TestParseMapping(t *testing.T) {
+	mapping, err := ParseMapping("age=edad:int, vip:bool,color")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]Param{
+		"age":   {Name: "edad", Type: TypeInt},
+		"vip":   {Type: TypeBool},
+		"color": {},
+	}
+
+	if diff := cmp.Diff(want, mapping); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := ParseMapping("age:number"); err == nil {
+		t.Error("want an error for unknown types")
+	}
+}
+
+func TestRunInputError(t *testing.T) {
+	errRead := errors.New("connection reset")
+
+	input := io.MultiReader(strings.NewReader("name,age,vip\nAna,31,true\nBea,\"4\"0,false\n"),
+		iotest.ErrReader(errRead))
+
+	var out bytes.Buffer
+
+	stats, err := Run(context.Background(), newFake(), input, &out, Config{
+		TreeID:       "scoring",
+		ReleaseID:    "production",
+		InputFormat:  FormatCSV,
+		OutputFormat: FormatCSV,
+		Mapping: map[string]Param{
+			"name": {},
+			"age":  {Type: TypeInt},
+			"vip":  {Type: TypeBool},
+		},
+		Concurrency: 1,
+	})
+	if !errors.Is(err, errRead) {
+		t.Errorf("want [%v] got [%v]", errRead, err)
+	}
+
+	if diff := cmp.Diff(Stats{Rows: 2, Succeeded: 1, Failed: 1}, stats); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
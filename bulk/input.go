@@ -0,0 +1,269 @@
+package bulk
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Formats of the input and output files.
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// Types a column can be coerced to.
+const (
+	TypeAuto   = ""
+	TypeString = "string"
+	TypeInt    = "int"
+	TypeFloat  = "float"
+	TypeBool   = "bool"
+	TypeJSON   = "json"
+)
+
+// ErrInvalidRow is wrapped by the errors of rows that can not be converted to params.
+var ErrInvalidRow = errors.New("invalid_row")
+
+// Param maps a column to an execution parameter.
+type Param struct {
+	// Name of the parameter, the column name when empty.
+	Name string
+	// Type the value is coerced to. TypeAuto keeps JSON values and converts
+	// CSV values to numbers or booleans when they look like one.
+	Type string
+}
+
+// ParseMapping parses a mapping such as "age=edad:int,vip:bool,color", each
+// entry being column[=param][:type].
+func ParseMapping(spec string) (map[string]Param, error) {
+	mapping := make(map[string]Param)
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		var param Param
+
+		if i := strings.LastIndex(entry, ":"); i >= 0 {
+			param.Type = entry[i+1:]
+			entry = entry[:i]
+		}
+
+		switch param.Type {
+		case TypeAuto, TypeString, TypeInt, TypeFloat, TypeBool, TypeJSON:
+		default:
+			return nil, fmt.Errorf("mapping %q: unknown type %q", entry, param.Type)
+		}
+
+		column := entry
+		if i := strings.Index(entry, "="); i >= 0 {
+			column, param.Name = entry[:i], entry[i+1:]
+		}
+
+		mapping[column] = param
+	}
+
+	return mapping, nil
+}
+
+// row is an input record, values are strings for CSV and JSON values for JSONL.
+type row struct {
+	index  int
+	values map[string]interface{}
+	err    error
+}
+
+// readRows sends the rows of in to rows until in is exhausted or done is closed.
+func readRows(in io.Reader, format string, rows chan<- row, done <-chan struct{}) error {
+	send := func(r row) bool {
+		select {
+		case rows <- r:
+			return true
+		case <-done:
+			return false
+		}
+	}
+
+	if format == FormatJSONL {
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+		for index := 0; scanner.Scan(); index++ {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				index--
+
+				continue
+			}
+
+			r := row{index: index}
+
+			if err := json.Unmarshal([]byte(line), &r.values); err != nil {
+				r.err = fmt.Errorf("%w: %v", ErrInvalidRow, err)
+			}
+
+			if !send(r) {
+				return nil
+			}
+		}
+
+		return scanner.Err()
+	}
+
+	reader := csv.NewReader(in)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		return fmt.Errorf("%w", err)
+	}
+
+	for index := 0; ; index++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		// malformed records are reported as invalid rows, other errors
+		// come from the input and end the run.
+		var parseErr *csv.ParseError
+		if err != nil && !errors.As(err, &parseErr) {
+			return fmt.Errorf("%w", err)
+		}
+
+		r := row{index: index, values: make(map[string]interface{}, len(header))}
+
+		if err != nil {
+			r.err = fmt.Errorf("%w: %v", ErrInvalidRow, err)
+		}
+
+		for i, value := range record {
+			if i < len(header) {
+				r.values[header[i]] = value
+			}
+		}
+
+		if !send(r) {
+			return nil
+		}
+	}
+}
+
+// params converts the values of a row to execution parameters, an empty
+// mapping passes every column with TypeAuto.
+func params(values map[string]interface{}, mapping map[string]Param) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(values))
+
+	if len(mapping) == 0 {
+		for column, value := range values {
+			result[column] = coerceAuto(value)
+		}
+
+		return result, nil
+	}
+
+	for column, param := range mapping {
+		value, ok := values[column]
+		if !ok {
+			continue
+		}
+
+		name := param.Name
+		if name == "" {
+			name = column
+		}
+
+		coerced, err := coerce(value, param.Type)
+		if err != nil {
+			return nil, fmt.Errorf("%w: column %s: %v", ErrInvalidRow, column, err)
+		}
+
+		result[name] = coerced
+	}
+
+	return result, nil
+}
+
+func coerceAuto(value interface{}) interface{} {
+	text, ok := value.(string)
+	if !ok {
+		return value
+	}
+
+	if number, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return number
+	}
+
+	if number, err := strconv.ParseFloat(text, 64); err == nil {
+		return number
+	}
+
+	if b, err := strconv.ParseBool(text); err == nil && (text == "true" || text == "false") {
+		return b
+	}
+
+	return text
+}
+
+func coerce(value interface{}, valueType string) (interface{}, error) {
+	text, isText := value.(string)
+
+	switch valueType {
+	case TypeAuto:
+		return coerceAuto(value), nil
+	case TypeString:
+		if isText {
+			return text, nil
+		}
+
+		content, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+
+		return string(content), nil
+	case TypeInt:
+		if number, ok := value.(float64); ok && number == float64(int64(number)) {
+			return int64(number), nil
+		}
+
+		return strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+	case TypeFloat:
+		if number, ok := value.(float64); ok {
+			return number, nil
+		}
+
+		return strconv.ParseFloat(strings.TrimSpace(text), 64)
+	case TypeBool:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+
+		return strconv.ParseBool(strings.TrimSpace(text))
+	case TypeJSON:
+		if !isText {
+			return value, nil
+		}
+
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(text), &decoded); err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+
+		return decoded, nil
+	}
+
+	return nil, fmt.Errorf("unknown type %q", valueType)
+}
@@ -0,0 +1,354 @@
+package builder
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// defaultCacheSize is the number of responses kept by the default cache.
+const defaultCacheSize = 1024
+
+// CacheEntry is a response stored in a Cache.
+type CacheEntry struct {
+	Response Response
+	StoredAt time.Time
+}
+
+// Cache stores the responses of executions, see NewMemoryCache. Shared
+// stores may serialize entries as JSON. It must be safe for concurrent use.
+type Cache interface {
+	Get(ctx context.Context, key string) (CacheEntry, bool)
+	Set(ctx context.Context, key string, entry CacheEntry)
+	Delete(ctx context.Context, key string)
+}
+
+// CachePolicy configures the caching of the executions of a tree.
+type CachePolicy struct {
+	// TTL is the time a response is served from the cache.
+	TTL time.Duration
+	// StaleWhileRevalidate is the time after TTL a response is still
+	// served while it is refreshed in the background.
+	StaleWhileRevalidate time.Duration
+}
+
+// WithCache sets the store of the response cache, an in-memory cache of
+// 1024 responses is used by default.
+func WithCache(cache Cache) Option {
+	return func(a *API) {
+		a.cache().store = cache
+	}
+}
+
+// WithTreeCache caches the sync executions of treeID, keyed by tenant,
+// tree, release and params. Only enable it for trees whose response is a
+// function of their params.
+func WithTreeCache(treeID string, policy CachePolicy) Option {
+	return func(a *API) {
+		a.cache().trees[treeID] = policy
+	}
+}
+
+// cache returns the response cache of the client, creating it if needed.
+func (a *API) cache() *responseCache {
+	if a.responseCache == nil {
+		a.responseCache = &responseCache{
+			trees:      make(map[string]CachePolicy),
+			versions:   make(map[releaseKey]string),
+			refreshing: make(map[string]bool),
+		}
+	}
+
+	return a.responseCache
+}
+
+type responseCache struct {
+	store Cache
+	trees map[string]CachePolicy
+
+	mu sync.Mutex
+	// versions is the last TreeVersion returned by Builder per tree and
+	// release, entries of other versions are stale.
+	versions   map[releaseKey]string
+	refreshing map[string]bool
+}
+
+// requestKey returns a key identifying the call of req, params are encoded
+// as JSON, which sorts map keys.
+func requestKey(tenantID string, req *Request) (string, error) {
+	params, err := json.Marshal(req.Params)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+
+	for _, part := range []string{tenantID, string(req.Operation), req.TreeID, req.ReleaseID,
+		req.SessionID, req.InteractionType} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+
+	h.Write(params)
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// lookup returns the entry of key unless it belongs to an old tree version.
+func (c *responseCache) lookup(ctx context.Context, key string, release releaseKey) (CacheEntry, bool) {
+	entry, ok := c.store.Get(ctx, key)
+	if !ok {
+		return CacheEntry{}, false
+	}
+
+	c.mu.Lock()
+	latest := c.versions[release]
+	c.mu.Unlock()
+
+	if latest != "" && entry.Response.TreeVersion != latest {
+		c.store.Delete(ctx, key)
+
+		return CacheEntry{}, false
+	}
+
+	return entry, true
+}
+
+// save stores response and records its tree version.
+func (c *responseCache) save(ctx context.Context, key string, release releaseKey, response Response) {
+	c.mu.Lock()
+	c.versions[release] = response.TreeVersion
+	c.mu.Unlock()
+
+	c.store.Set(ctx, key, CacheEntry{Response: copyResponse(response), StoredAt: time.Now()})
+}
+
+// startRefresh reports whether the caller must refresh key, at most one
+// refresh per key runs at a time.
+func (c *responseCache) startRefresh(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.refreshing[key] {
+		return false
+	}
+
+	c.refreshing[key] = true
+
+	return true
+}
+
+func (c *responseCache) endRefresh(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.refreshing, key)
+}
+
+// cacheMiddleware serves the sync executions of cached trees from the cache.
+func (a *API) cacheMiddleware(next Handler) Handler {
+	c := a.responseCache
+
+	return func(ctx context.Context, req *Request) (Response, error) {
+		policy, ok := c.trees[req.TreeID]
+		if !ok || req.Operation != OperationExecution {
+			return next(ctx, req)
+		}
+
+		key, err := requestKey(a.TenantID, req)
+		if err != nil {
+			return next(ctx, req)
+		}
+
+		release := releaseKey{treeID: req.TreeID, releaseID: req.ReleaseID}
+
+		if entry, ok := c.lookup(ctx, key, release); ok {
+			age := time.Since(entry.StoredAt)
+
+			// the cached response was made by another call, with its own key.
+			response := copyResponse(entry.Response)
+			response.IdempotencyKey = req.IdempotencyKey
+
+			if age < policy.TTL {
+				return response, nil
+			}
+
+			if age < policy.TTL+policy.StaleWhileRevalidate {
+				if c.startRefresh(key) {
+					refresh := copyRequest(req)
+					refresh.IdempotencyKey = ""
+
+					go a.refreshCache(next, refresh, key, release)
+				}
+
+				return response, nil
+			}
+		}
+
+		response, err := next(ctx, req)
+		if err != nil {
+			return response, err
+		}
+
+		c.save(ctx, key, release, response)
+
+		return response, nil
+	}
+}
+
+// refreshCache executes req in the background to refresh the stale entry of key.
+func (a *API) refreshCache(next Handler, req *Request, key string, release releaseKey) {
+	defer a.responseCache.endRefresh(key)
+
+	ctx := context.Background()
+
+	response, err := next(ctx, req)
+	if err != nil {
+		if a.logger != nil {
+			a.logger.ErrorContext(ctx, "error refreshing cached response",
+				"tree_id", req.TreeID, "release_id", req.ReleaseID, "error", err)
+		}
+
+		return
+	}
+
+	a.responseCache.save(ctx, key, release, response)
+}
+
+// copyRequest returns a copy of req whose header can be modified.
+func copyRequest(req *Request) *Request {
+	copied := *req
+	copied.Header = req.Header.Clone()
+
+	return &copied
+}
+
+// copyResponse returns a deep copy of response, so callers can modify its Vars.
+func copyResponse(response Response) Response {
+	if response.Data.Vars != nil {
+		response.Data.Vars = copyValue(response.Data.Vars).(map[string]interface{})
+	}
+
+	return response
+}
+
+func copyValue(value interface{}) interface{} {
+	return copyRedacted(value, nil)
+}
+
+// copyRedacted deep copies value like copyValue, replacing the values of the
+// map keys in names with redacted at any depth.
+func copyRedacted(value interface{}, names map[string]bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if names[key] {
+				copied[key] = redacted
+
+				continue
+			}
+
+			copied[key] = copyRedacted(item, names)
+		}
+
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = copyRedacted(item, names)
+		}
+
+		return copied
+	}
+
+	return value
+}
+
+// MemoryCache is an in-memory Cache evicting the least recently used entries.
+type MemoryCache struct {
+	size int
+
+	mu      sync.Mutex
+	entries *list.List
+	keys    map[string]*list.Element
+}
+
+var _ Cache = (*MemoryCache)(nil)
+
+type memoryCacheItem struct {
+	key   string
+	entry CacheEntry
+}
+
+// NewMemoryCache creates a MemoryCache holding up to size entries.
+func NewMemoryCache(size int) *MemoryCache {
+	if size <= 0 {
+		size = defaultCacheSize
+	}
+
+	return &MemoryCache{
+		size:    size,
+		entries: list.New(),
+		keys:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the entry of key.
+func (c *MemoryCache) Get(ctx context.Context, key string) (CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.keys[key]
+	if !ok {
+		return CacheEntry{}, false
+	}
+
+	c.entries.MoveToFront(element)
+
+	return element.Value.(*memoryCacheItem).entry, true
+}
+
+// Set stores entry, evicting the least recently used entry when full.
+func (c *MemoryCache) Set(ctx context.Context, key string, entry CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.keys[key]; ok {
+		element.Value.(*memoryCacheItem).entry = entry
+		c.entries.MoveToFront(element)
+
+		return
+	}
+
+	c.keys[key] = c.entries.PushFront(&memoryCacheItem{key: key, entry: entry})
+
+	if c.entries.Len() > c.size {
+		oldest := c.entries.Back()
+		c.entries.Remove(oldest)
+		delete(c.keys, oldest.Value.(*memoryCacheItem).key)
+	}
+}
+
+// Delete removes the entry of key.
+func (c *MemoryCache) Delete(ctx context.Context, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.keys[key]; ok {
+		c.entries.Remove(element)
+		delete(c.keys, key)
+	}
+}
+
+// Len returns the number of entries.
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.entries.Len()
+}
@@ -0,0 +1,188 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newCacheServer(t *testing.T, calls *int64, version *int64) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := atomic.AddInt64(calls, 1)
+
+		body := fmt.Sprintf(`{"tree_version": "%d", "response_type": "COMMON",
+			"data": {"vars": {"call": %d, "tags": ["a"]}}}`, atomic.LoadInt64(version), call)
+
+		_, err := w.Write([]byte(body))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v]", err)
+		}
+	}))
+}
+
+func TestCacheTTL(t *testing.T) {
+	var calls, version int64 = 0, 3
+
+	server := newCacheServer(t, &calls, &version)
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL),
+		WithTreeCache("tree_1", CachePolicy{TTL: time.Minute}))
+
+	params := map[string]interface{}{"age": 10, "country": "CL"}
+
+	first, err := client.AddExecution("tree_1", "release_1", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first.Data.Vars["call"] = "modified"
+	first.Data.Vars["tags"].([]interface{})[0] = "modified"
+
+	second, err := client.AddExecution("tree_1", "release_1", map[string]interface{}{"country": "CL", "age": 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if second.Data.Vars["call"] != float64(1) || second.Data.Vars["tags"].([]interface{})[0] != "a" {
+		t.Errorf("want the cached response unmodified got [%v]", second.Data.Vars)
+	}
+
+	if _, err := client.AddExecution("tree_1", "release_1", map[string]interface{}{"age": 11}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.AddExecution("tree_2", "release_1", params); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.AddExecution("tree_2", "release_1", params); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := atomic.LoadInt64(&calls); got != 4 {
+		t.Errorf("want [4] calls got [%d]", got)
+	}
+}
+
+func TestCacheStaleWhileRevalidate(t *testing.T) {
+	var calls, version int64 = 0, 3
+
+	server := newCacheServer(t, &calls, &version)
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL),
+		WithTreeCache("tree_1", CachePolicy{TTL: 20 * time.Millisecond, StaleWhileRevalidate: time.Minute}))
+
+	if _, err := client.AddExecution("tree_1", "release_1", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	stale, err := client.AddExecution("tree_1", "release_1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stale.Data.Vars["call"] != float64(1) {
+		t.Errorf("want the stale response got [%v]", stale.Data.Vars)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt64(&calls) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	var fresh Response
+
+	for time.Now().Before(deadline) {
+		fresh, err = client.AddExecution("tree_1", "release_1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if fresh.Data.Vars["call"] == float64(2) {
+			break
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	if fresh.Data.Vars["call"] != float64(2) {
+		t.Errorf("want the refreshed response got [%v]", fresh.Data.Vars)
+	}
+}
+
+func TestCacheTreeVersionInvalidation(t *testing.T) {
+	var calls, version int64 = 0, 3
+
+	server := newCacheServer(t, &calls, &version)
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL),
+		WithTreeCache("tree_1", CachePolicy{TTL: time.Minute}))
+
+	a := map[string]interface{}{"age": 10}
+	b := map[string]interface{}{"age": 11}
+
+	for _, params := range []map[string]interface{}{a, b} {
+		if _, err := client.AddExecution("tree_1", "release_1", params); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	atomic.StoreInt64(&version, 4)
+
+	// a new tree version seen on a miss invalidates the entry of a.
+	if _, err := client.AddExecution("tree_1", "release_1", map[string]interface{}{"age": 12}); err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := client.AddExecution("tree_1", "release_1", a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.TreeVersion != "4" {
+		t.Errorf("want [%s] got [%s]", "4", response.TreeVersion)
+	}
+
+	if got := atomic.LoadInt64(&calls); got != 4 {
+		t.Errorf("want [4] calls got [%d]", got)
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryCache(2)
+
+	cache.Set(ctx, "a", CacheEntry{Response: Response{RequestID: "a"}})
+	cache.Set(ctx, "b", CacheEntry{Response: Response{RequestID: "b"}})
+
+	if _, ok := cache.Get(ctx, "a"); !ok {
+		t.Fatal("want [a] cached")
+	}
+
+	cache.Set(ctx, "c", CacheEntry{Response: Response{RequestID: "c"}})
+
+	if _, ok := cache.Get(ctx, "b"); ok {
+		t.Error("want [b] evicted")
+	}
+
+	if entry, ok := cache.Get(ctx, "a"); !ok || entry.Response.RequestID != "a" {
+		t.Errorf("want [a] got [%v]", entry)
+	}
+
+	cache.Delete(ctx, "a")
+
+	if cache.Len() != 1 {
+		t.Errorf("want [1] got [%d]", cache.Len())
+	}
+}
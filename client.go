@@ -1,6 +1,7 @@
 package builder
 
 import (
+	"context"
 	"net/http"
 	"time"
 )
@@ -12,7 +13,7 @@ const defaultHTTPTimeout = 120 * time.Second
 const APIURL string = "https://builder.api.reevolute.com"
 
 // clientversion should be the same as tag, used for seeting user-agent.
-const clientversion = "0.2.0"
+const clientversion = "0.3.0"
 
 // ResponseData data component from Builder response.
 type ResponseData struct {
@@ -23,11 +24,13 @@ type ResponseData struct {
 
 // Response result of Builder execution.
 type Response struct {
-	SessionID    string
-	RequestID    string
-	TreeVersion  string
-	ResponseType string
-	Data         ResponseData
+	SessionID      string
+	RequestID      string
+	TraceID        string
+	IdempotencyKey string
+	TreeVersion    string
+	ResponseType   string
+	Data           ResponseData
 }
 
 // Client interface.
@@ -38,12 +41,50 @@ type Client interface {
 	GetSessionInformation(sessionID string) (Response, error)
 }
 
+// ContextClient interface, same as Client but every call carries a context
+// used for cancellation and deadlines.
+type ContextClient interface {
+	AddExecutionContext(ctx context.Context, treeID, releaseID string,
+		params map[string]interface{}) (Response, error)
+	AddAsyncExecutionContext(ctx context.Context, treeID, releaseID string,
+		params map[string]interface{}) (string, error)
+	AddInteractionContext(ctx context.Context, sessionID, interactionType string,
+		params map[string]interface{}) (Response, error)
+	GetSessionInformationContext(ctx context.Context, sessionID string) (Response, error)
+}
+
+var (
+	_ Client        = (*API)(nil)
+	_ ContextClient = (*API)(nil)
+)
+
 // API is the builder client implementation.
 type API struct {
 	httpClient *http.Client
 	apiKey     string
 	apiURL     string
-	TenantID   string
+	timeout    time.Duration
+	userAgent  string
+
+	retryPolicy RetryPolicy
+	limiter     *rateLimiter
+	breaker     *circuitBreaker
+
+	responseCache *responseCache
+	flights       *flightGroup
+
+	idempotencyKeys bool
+
+	tracer  Tracer
+	metrics MetricsRecorder
+
+	logger         Logger
+	redactedParams map[string]bool
+
+	middleware []Middleware
+	handler    Handler
+
+	TenantID string
 }
 
 // The default HTTP client used for communications with builder.
@@ -55,13 +96,30 @@ func getDefaultHTTPClient() *http.Client {
 
 // New creates a new Builder client with the appropriate secret key
 // and the tenantID associated.
-func New(key string, tenantID string) *API {
+func New(key string, tenantID string, opts ...Option) *API {
 	api := API{
 		httpClient: getDefaultHTTPClient(),
 		apiKey:     key,
 		apiURL:     APIURL,
+		limiter:    &rateLimiter{},
 		TenantID:   tenantID,
 	}
 
+	for _, opt := range opts {
+		opt(&api)
+	}
+
+	if api.timeout > 0 {
+		httpClient := *api.httpClient
+		httpClient.Timeout = api.timeout
+		api.httpClient = &httpClient
+	}
+
+	if api.responseCache != nil && api.responseCache.store == nil {
+		api.responseCache.store = NewMemoryCache(defaultCacheSize)
+	}
+
+	api.handler = api.chain()
+
 	return &api
 }
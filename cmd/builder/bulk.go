@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/reevolute/builder-go"
+	"github.com/reevolute/builder-go/bulk"
+)
+
+func (c *cli) bulk(ctx context.Context, args []string) error {
+	fs := c.flagSet("bulk")
+	in := fs.String("in", "-", "CSV or JSONL input file, - for stdin")
+	out := fs.String("out", "-", "CSV or JSONL output file, - for stdout")
+	inFormat := fs.String("in-format", "", "input format, csv or jsonl, by default from the file extension")
+	outFormat := fs.String("out-format", "", "output format, csv or jsonl, by default from the file extension")
+	mapping := fs.String("map", "", "column mapping, column[=param][:type] separated by commas")
+	vars := fs.String("vars", "", "vars written as CSV columns, separated by commas")
+	concurrency := fs.Int("concurrency", 4, "executions run at the same time")
+	rate := fs.Float64("rate", 0, "maximum executions per second, 0 for no limit")
+	checkpoint := fs.String("checkpoint", "", "checkpoint file to resume an interrupted run")
+
+	if err := fs.Parse(args); err != nil || fs.NArg() != 2 {
+		return errUsage
+	}
+
+	cfg := bulk.Config{
+		TreeID:       fs.Arg(0),
+		ReleaseID:    fs.Arg(1),
+		InputFormat:  fileFormat(*inFormat, *in),
+		OutputFormat: fileFormat(*outFormat, *out),
+		Concurrency:  *concurrency,
+		Checkpoint:   *checkpoint,
+	}
+
+	if *vars != "" {
+		cfg.Vars = strings.Split(*vars, ",")
+	}
+
+	if *mapping != "" {
+		m, err := bulk.ParseMapping(*mapping)
+		if err != nil {
+			return fmt.Errorf("%w", err)
+		}
+
+		cfg.Mapping = m
+	}
+
+	var reader io.Reader = c.stdin
+
+	if *in != "-" {
+		file, err := os.Open(*in)
+		if err != nil {
+			return fmt.Errorf("%w", err)
+		}
+
+		defer file.Close()
+
+		reader = file
+	}
+
+	var writer io.Writer = c.stdout
+
+	if *out != "-" {
+		// A resumed run appends to the output of the interrupted one.
+		flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if info, err := os.Stat(*checkpoint); *checkpoint != "" && err == nil && info.Size() > 0 {
+			flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+
+		file, err := os.OpenFile(*out, flags, 0o644)
+		if err != nil {
+			return fmt.Errorf("%w", err)
+		}
+
+		defer file.Close()
+
+		writer = file
+	}
+
+	client := c.client
+	if *rate > 0 {
+		client = c.newClient(builder.WithTreeRateLimit(cfg.TreeID, builder.RateLimit{Rate: *rate, Burst: 1}))
+	}
+
+	stats, err := bulk.Run(ctx, client, reader, writer, cfg)
+
+	fmt.Fprintf(c.stderr, "rows %d, skipped %d, succeeded %d, failed %d\n",
+		stats.Rows, stats.Skipped, stats.Succeeded, stats.Failed)
+
+	return err
+}
+
+// fileFormat returns format or the format of the file extension.
+func fileFormat(format, path string) string {
+	if format != "" {
+		return format
+	}
+
+	switch filepath.Ext(path) {
+	case ".jsonl", ".ndjson":
+		return bulk.FormatJSONL
+	}
+
+	return bulk.FormatCSV
+}
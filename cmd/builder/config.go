@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// config holds the credentials of the CLI, read from the config file and
+// overridden by the environment.
+type config struct {
+	APIKey   string `json:"api_key"`
+	TenantID string `json:"tenant_id"`
+	URL      string `json:"url"`
+}
+
+var errMissingCredentials = errors.New("missing credentials, set BUILDER_API_KEY and BUILDER_TENANT_ID")
+
+// defaultConfigPath returns $XDG_CONFIG_HOME/builder/config.json or its
+// equivalent for the platform.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "builder", "config.json")
+}
+
+func loadConfig(path string, getenv func(string) string) (config, error) {
+	var cfg config
+
+	if path != "" {
+		content, err := os.ReadFile(path)
+
+		switch {
+		case err == nil:
+			if err := json.Unmarshal(content, &cfg); err != nil {
+				return config{}, fmt.Errorf("config %s: %w", path, err)
+			}
+		case !errors.Is(err, os.ErrNotExist):
+			return config{}, fmt.Errorf("%w", err)
+		}
+	}
+
+	if value := getenv("BUILDER_API_KEY"); value != "" {
+		cfg.APIKey = value
+	}
+
+	if value := getenv("BUILDER_TENANT_ID"); value != "" {
+		cfg.TenantID = value
+	}
+
+	if value := getenv("BUILDER_URL"); value != "" {
+		cfg.URL = value
+	}
+
+	if cfg.APIKey == "" || cfg.TenantID == "" {
+		return config{}, errMissingCredentials
+	}
+
+	return cfg, nil
+}
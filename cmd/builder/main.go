@@ -0,0 +1,243 @@
+// Command builder runs executions, interactions and session lookups against
+// Builder from the command line.
+//
+// Credentials are read from the config file, by default
+// $XDG_CONFIG_HOME/builder/config.json:
+//
+//	{"api_key": "...", "tenant_id": "...", "url": "..."}
+//
+// and overridden by BUILDER_API_KEY, BUILDER_TENANT_ID and BUILDER_URL.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/reevolute/builder-go"
+)
+
+const usage = `Usage: builder [flags] <command> [command flags] [args]
+
+Commands:
+  exec [-params file] <tree> <release> [key=value...]        sync execution
+  exec-async [-params file] <tree> <release> [key=value...]  async execution, prints the session and request IDs
+  interact [-type t] [-params file] <session> [key=value...] interaction on a session
+  session show <session>                                     session information
+  wait [-interval d] <session>                               waits for the result of an async execution
+  bulk [-in file] [-out file] [-map spec] <tree> <release>   executions for every row of a CSV or JSONL file
+
+Params are key=value arguments, values that are valid JSON are decoded.
+-params reads a JSON object from a file, - for stdin.
+
+Flags:
+`
+
+var errUsage = errors.New("invalid usage")
+
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	format string
+	client *builder.API
+	// newClient creates a client with the config and flags of the command
+	// and opts, for commands needing extra options.
+	newClient func(opts ...builder.Option) *builder.API
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr, os.Getenv))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer, getenv func(string) string) int {
+	fs := flag.NewFlagSet("builder", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+
+	configPath := fs.String("config", defaultConfigPath(), "config file")
+	baseURL := fs.String("url", "", "Builder API base URL, overrides the config")
+	format := fs.String("o", formatTable, "output format, table or json")
+	timeout := fs.Duration("timeout", 0, "timeout of the command, none by default")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if fs.NArg() == 0 || (*format != formatTable && *format != formatJSON) {
+		fs.Usage()
+
+		return 2
+	}
+
+	cfg, err := loadConfig(*configPath, getenv)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+
+		return 1
+	}
+
+	if *baseURL != "" {
+		cfg.URL = *baseURL
+	}
+
+	opts := []builder.Option{builder.WithUserAgent("builder-cli")}
+	if cfg.URL != "" {
+		opts = append(opts, builder.WithBaseURL(cfg.URL))
+	}
+
+	c := cli{
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+		format: *format,
+		client: builder.New(cfg.APIKey, cfg.TenantID, opts...),
+		newClient: func(extra ...builder.Option) *builder.API {
+			return builder.New(cfg.APIKey, cfg.TenantID, append(append([]builder.Option{}, opts...), extra...)...)
+		},
+	}
+
+	// wait and bulk may run for long, every call to Builder is still bounded
+	// by the timeout of the client.
+	ctx := context.Background()
+
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	err = c.dispatch(ctx, fs.Arg(0), fs.Args()[1:])
+
+	switch {
+	case errors.Is(err, errUsage):
+		fs.Usage()
+
+		return 2
+	case err != nil:
+		fmt.Fprintf(stderr, "Error: %s\n", formatError(err))
+
+		return 1
+	}
+
+	return 0
+}
+
+func (c *cli) dispatch(ctx context.Context, command string, args []string) error {
+	switch command {
+	case "exec":
+		return c.exec(ctx, args, false)
+	case "exec-async":
+		return c.exec(ctx, args, true)
+	case "interact":
+		return c.interact(ctx, args)
+	case "session":
+		return c.session(ctx, args)
+	case "wait":
+		return c.wait(ctx, args)
+	case "bulk":
+		return c.bulk(ctx, args)
+	}
+
+	return fmt.Errorf("%w: unknown command %q", errUsage, command)
+}
+
+func (c *cli) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+
+	return fs
+}
+
+func (c *cli) exec(ctx context.Context, args []string, async bool) error {
+	fs := c.flagSet("exec")
+	paramsFile := fs.String("params", "", "JSON file with the params, - for stdin")
+
+	if err := fs.Parse(args); err != nil || fs.NArg() < 2 {
+		return errUsage
+	}
+
+	params, err := parseParams(*paramsFile, fs.Args()[2:], c.stdin)
+	if err != nil {
+		return err
+	}
+
+	if async {
+		response, err := c.client.AddAsyncExecutionResponseContext(ctx, fs.Arg(0), fs.Arg(1), params)
+		if err != nil {
+			return err
+		}
+
+		return printAsync(c.stdout, c.format, response)
+	}
+
+	response, err := c.client.AddExecutionContext(ctx, fs.Arg(0), fs.Arg(1), params)
+	if err != nil {
+		return err
+	}
+
+	return printResponse(c.stdout, c.format, response)
+}
+
+func (c *cli) interact(ctx context.Context, args []string) error {
+	fs := c.flagSet("interact")
+	interactionType := fs.String("type", builder.InteractionTypeContinue, "interaction type")
+	paramsFile := fs.String("params", "", "JSON file with the params, - for stdin")
+
+	if err := fs.Parse(args); err != nil || fs.NArg() < 1 {
+		return errUsage
+	}
+
+	params, err := parseParams(*paramsFile, fs.Args()[1:], c.stdin)
+	if err != nil {
+		return err
+	}
+
+	response, err := c.client.AddInteractionContext(ctx, fs.Arg(0), *interactionType, params)
+	if err != nil {
+		return err
+	}
+
+	return printResponse(c.stdout, c.format, response)
+}
+
+func (c *cli) session(ctx context.Context, args []string) error {
+	if len(args) != 2 || args[0] != "show" {
+		return errUsage
+	}
+
+	response, err := c.client.GetSessionInformationContext(ctx, args[1])
+	if err != nil {
+		return err
+	}
+
+	return printResponse(c.stdout, c.format, response)
+}
+
+func (c *cli) wait(ctx context.Context, args []string) error {
+	fs := c.flagSet("wait")
+	interval := fs.Duration("interval", time.Second, "interval between polls")
+
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return errUsage
+	}
+
+	response, err := c.client.WaitForExecution(ctx, fs.Arg(0), builder.WithPollInterval(*interval))
+	if err != nil {
+		return err
+	}
+
+	return printResponse(c.stdout, c.format, response)
+}
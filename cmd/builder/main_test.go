@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// newStub serves the color_pick tree: a color param answers its color, no
+// color asks for the size, sent with a continue interaction.
+func newStub(t *testing.T) (*httptest.Server, func(string) string) {
+	t.Helper()
+
+	const tenantPath = "/v2/tenants/my_tenant_1312/"
+
+	var (
+		mu       sync.Mutex
+		sessions = make(map[string]string)
+		count    int
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var requestBody struct {
+			Parameters      map[string]interface{} `json:"parameters"`
+			InteractionType string                 `json:"type"`
+		}
+
+		if r.Method == http.MethodPost {
+			if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+				t.Errorf("Error request body %v", err)
+			}
+		}
+
+		w.Header().Set("X-Trace-Id", "trace_1")
+		w.Header().Set("X-Request-Id", fmt.Sprintf("request_%d", count))
+
+		path := strings.TrimPrefix(r.URL.Path, tenantPath)
+		sessionID := strings.TrimSuffix(strings.TrimPrefix(path, "executions/"), "/interactions")
+
+		var body string
+
+		switch {
+		case path == "trees/color_pick/releases/production/executions":
+			count++
+			sessionID = fmt.Sprintf("session_%d", count)
+
+			switch color := requestBody.Parameters["color"]; color {
+			case nil:
+				body = `{"tree_version": "3", "response_type": "INPUT",
+					"data": {"vars": {"requested_vars": ["size"]}}}`
+			case "red":
+				body = `{"tree_version": "3", "response_type": "COMMON",
+					"data": {"description": "function evaluation", "error_code": "0",
+					"vars": {"child_response": "red", "concat_response": "COLOR: rojo"}}}`
+			default:
+				body = fmt.Sprintf(`{"tree_version": "3", "response_type": "COMMON",
+					"data": {"error_code": "0", "vars": {"child_response": %q}}}`, color)
+			}
+
+			sessions[sessionID] = body
+		case strings.HasSuffix(path, "/executions"):
+			w.WriteHeader(http.StatusNotFound)
+			body = `{"error": "tree_not_found"}`
+		case strings.HasSuffix(path, "/interactions") && requestBody.Parameters["size"] == "L":
+			body = `{"tree_version": "3", "response_type": "COMMON",
+				"data": {"error_code": "0", "vars": {"size": 42}}}`
+			sessions[sessionID] = body
+		case r.Method == http.MethodGet && sessions[sessionID] != "":
+			body = sessions[sessionID]
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			body = `{"error": "unexpected_request"}`
+		}
+
+		w.Header().Set("X-Session-Id", sessionID)
+
+		if requestBody.InteractionType == "async" {
+			w.WriteHeader(http.StatusCreated)
+
+			return
+		}
+
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Errorf("Error writing response httptest Server [%v]", err)
+		}
+	}))
+
+	env := map[string]string{
+		"BUILDER_API_KEY":   "aabbcc",
+		"BUILDER_TENANT_ID": "my_tenant_1312",
+		"BUILDER_URL":       server.URL,
+	}
+
+	return server, func(key string) string { return env[key] }
+}
+
+func TestRunExec(t *testing.T) {
+	server, getenv := newStub(t)
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{"-config", "", "-o", "json", "exec", "color_pick", "production", "color=red"},
+		nil, &stdout, &stderr, getenv)
+	if code != 0 {
+		t.Fatalf("want exit code [0] got [%d]: %s", code, stderr.String())
+	}
+
+	var output responseOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		t.Fatal(err)
+	}
+
+	if output.Data.Vars["child_response"] != "red" || output.SessionID == "" {
+		t.Errorf("unexpected output %s", stdout.String())
+	}
+
+	stdout.Reset()
+
+	code = run([]string{"-config", "", "session", "show", output.SessionID}, nil, &stdout, &stderr, getenv)
+	if code != 0 {
+		t.Fatalf("want exit code [0] got [%d]: %s", code, stderr.String())
+	}
+
+	if !strings.Contains(stdout.String(), "child_response   red") {
+		t.Errorf("want the vars table got %s", stdout.String())
+	}
+}
+
+func TestRunInteractFromStdin(t *testing.T) {
+	server, getenv := newStub(t)
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{"-config", "", "-o", "json", "exec", "color_pick", "production"},
+		nil, &stdout, &stderr, getenv)
+	if code != 0 {
+		t.Fatalf("want exit code [0] got [%d]: %s", code, stderr.String())
+	}
+
+	var output responseOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout.Reset()
+
+	code = run([]string{"-config", "", "-o", "json", "interact", "-params", "-", output.SessionID},
+		strings.NewReader(`{"size": "L"}`), &stdout, &stderr, getenv)
+	if code != 0 {
+		t.Fatalf("want exit code [0] got [%d]: %s", code, stderr.String())
+	}
+
+	if !strings.Contains(stdout.String(), `"size": 42`) {
+		t.Errorf("unexpected output %s", stdout.String())
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	server, getenv := newStub(t)
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{"-config", "", "exec", "unknown", "production"}, nil, &stdout, &stderr, getenv)
+	if code != 1 || !strings.Contains(stderr.String(), "tree_not_found") ||
+		!strings.Contains(stderr.String(), "trace id") {
+		t.Errorf("want exit code [1] with the error got [%d]: %s", code, stderr.String())
+	}
+
+	code = run([]string{"-config", "", "exec", "color_pick"}, nil, &stdout, &stderr, getenv)
+	if code != 2 {
+		t.Errorf("want exit code [2] got [%d]", code)
+	}
+
+	stderr.Reset()
+
+	code = run([]string{"-config", "", "exec", "color_pick", "production"}, nil, &stdout, &stderr,
+		func(string) string { return "" })
+	if code != 1 || !strings.Contains(stderr.String(), errMissingCredentials.Error()) {
+		t.Errorf("want exit code [1] got [%d]: %s", code, stderr.String())
+	}
+}
+
+func TestParseParams(t *testing.T) {
+	params, err := parseParams("", []string{"color=red", "age=42", "vip=true", "tags=[\"a\"]", "note=a=b"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{
+		"color": "red",
+		"age":   float64(42),
+		"vip":   true,
+		"tags":  []interface{}{"a"},
+		"note":  "a=b",
+	}
+
+	if diff := cmp.Diff(want, params); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := parseParams("", []string{"color"}, nil); err == nil {
+		t.Error("want an error for params without =")
+	}
+}
+
+func TestRunBulk(t *testing.T) {
+	server, getenv := newStub(t)
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{"-config", "", "bulk", "-vars", "child_response", "color_pick", "production"},
+		strings.NewReader("color\nred\nblue\n"), &stdout, &stderr, getenv)
+	if code != 0 {
+		t.Fatalf("want exit code [0] got [%d]: %s", code, stderr.String())
+	}
+
+	if !strings.Contains(stdout.String(), ",COMMON,red\n") || !strings.Contains(stdout.String(), ",COMMON,blue\n") {
+		t.Errorf("unexpected output %s", stdout.String())
+	}
+
+	if !strings.Contains(stderr.String(), "rows 2, skipped 0, succeeded 2, failed 0") {
+		t.Errorf("unexpected stats %s", stderr.String())
+	}
+}
+
+func TestRunWait(t *testing.T) {
+	server, getenv := newStub(t)
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{"-config", "", "-o", "json", "exec-async", "color_pick", "production", "color=red"},
+		nil, &stdout, &stderr, getenv)
+	if code != 0 {
+		t.Fatalf("want exit code [0] got [%d]: %s", code, stderr.String())
+	}
+
+	var output asyncOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		t.Fatal(err)
+	}
+
+	if output.SessionID == "" || output.RequestID == "" {
+		t.Fatalf("want the session and request IDs got %s", stdout.String())
+	}
+
+	stdout.Reset()
+
+	code = run([]string{"-config", "", "wait", "-interval", "1ms", output.SessionID}, nil, &stdout, &stderr, getenv)
+	if code != 0 {
+		t.Fatalf("want exit code [0] got [%d]: %s", code, stderr.String())
+	}
+
+	if !strings.Contains(stdout.String(), "child_response   red") {
+		t.Errorf("want the vars table got %s", stdout.String())
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	server, getenv := newStub(t)
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{"-config", "", "-timeout", "1ns", "exec", "color_pick", "production"},
+		nil, &stdout, &stderr, getenv)
+	if code != 1 || !strings.Contains(stderr.String(), context.DeadlineExceeded.Error()) {
+		t.Errorf("want exit code [1] with a deadline error got [%d]: %s", code, stderr.String())
+	}
+}
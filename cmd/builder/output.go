@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/reevolute/builder-go"
+)
+
+const (
+	formatJSON  = "json"
+	formatTable = "table"
+)
+
+type responseOutput struct {
+	SessionID      string               `json:"session_id"`
+	RequestID      string               `json:"request_id"`
+	TraceID        string               `json:"trace_id,omitempty"`
+	IdempotencyKey string               `json:"idempotency_key,omitempty"`
+	TreeVersion    string               `json:"tree_version"`
+	ResponseType   string               `json:"response_type"`
+	Data           builder.ResponseData `json:"data"`
+}
+
+func printResponse(w io.Writer, format string, response builder.Response) error {
+	if format == formatJSON {
+		return printJSON(w, responseOutput{
+			SessionID:      response.SessionID,
+			RequestID:      response.RequestID,
+			TraceID:        response.TraceID,
+			IdempotencyKey: response.IdempotencyKey,
+			TreeVersion:    response.TreeVersion,
+			ResponseType:   response.ResponseType,
+			Data:           response.Data,
+		})
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "SESSION ID\t%s\n", response.SessionID)
+	fmt.Fprintf(tw, "REQUEST ID\t%s\n", response.RequestID)
+
+	if response.TraceID != "" {
+		fmt.Fprintf(tw, "TRACE ID\t%s\n", response.TraceID)
+	}
+
+	fmt.Fprintf(tw, "TREE VERSION\t%s\n", response.TreeVersion)
+	fmt.Fprintf(tw, "RESPONSE TYPE\t%s\n", response.ResponseType)
+	fmt.Fprintf(tw, "DESCRIPTION\t%s\n", response.Data.Description)
+	fmt.Fprintf(tw, "ERROR CODE\t%s\n", response.Data.ErrorCode)
+
+	if len(response.Data.Vars) > 0 {
+		names := make([]string, 0, len(response.Data.Vars))
+		for name := range response.Data.Vars {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		fmt.Fprintf(tw, "\nVAR\tVALUE\n")
+
+		for _, name := range names {
+			fmt.Fprintf(tw, "%s\t%s\n", name, formatValue(response.Data.Vars[name]))
+		}
+	}
+
+	return tw.Flush()
+}
+
+type asyncOutput struct {
+	SessionID      string `json:"session_id"`
+	RequestID      string `json:"request_id"`
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+}
+
+// printAsync prints the IDs of an async execution, wait takes its session ID.
+func printAsync(w io.Writer, format string, response builder.Response) error {
+	if format == formatJSON {
+		return printJSON(w, asyncOutput{
+			SessionID:      response.SessionID,
+			RequestID:      response.RequestID,
+			IdempotencyKey: response.IdempotencyKey,
+		})
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "SESSION ID\t%s\n", response.SessionID)
+	fmt.Fprintf(tw, "REQUEST ID\t%s\n", response.RequestID)
+
+	return tw.Flush()
+}
+
+func printJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(v)
+}
+
+func formatValue(value interface{}) string {
+	if text, ok := value.(string); ok {
+		return text
+	}
+
+	content, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	return string(content)
+}
+
+// formatError adds the details of Builder errors, the trace ID is what
+// support asks for.
+func formatError(err error) string {
+	var apiErr *builder.APIError
+	if errors.As(err, &apiErr) {
+		return fmt.Sprintf("%v (status %d, code %q, request id %s, trace id %s)",
+			err, apiErr.StatusCode, apiErr.Code, apiErr.RequestID, apiErr.TraceID)
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// parseParams builds execution parameters from a JSON file, "-" for stdin,
+// and key=value arguments. Values that are valid JSON are decoded, so
+// age=42 is a number and color=red a string.
+func parseParams(file string, args []string, stdin io.Reader) (map[string]interface{}, error) {
+	params := make(map[string]interface{})
+
+	if file != "" {
+		var content []byte
+
+		var err error
+
+		if file == "-" {
+			content, err = io.ReadAll(stdin)
+		} else {
+			content, err = os.ReadFile(file)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+
+		if err := json.Unmarshal(content, &params); err != nil {
+			return nil, fmt.Errorf("params %s: %w", file, err)
+		}
+	}
+
+	for _, arg := range args {
+		key, value, ok := cut(arg, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid param %q, want key=value", arg)
+		}
+
+		params[key] = parseValue(value)
+	}
+
+	return params, nil
+}
+
+func parseValue(value string) interface{} {
+	var decoded interface{}
+
+	if err := json.Unmarshal([]byte(value), &decoded); err == nil {
+		return decoded
+	}
+
+	return value
+}
+
+func cut(s, sep string) (string, string, bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+
+	return s, "", false
+}
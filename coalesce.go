@@ -0,0 +1,150 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// errFlightPanicked is returned to the callers sharing a call that panicked,
+// the panic goes on in the caller that made it.
+var errFlightPanicked = errors.New("coalesced_call_panicked")
+
+// WithCoalescing makes concurrent identical session lookups share a single
+// call to Builder, every caller gets its own copy of the Response. Sync
+// executions are not idempotent, they are only coalesced when they carry
+// the same idempotency key, see ContextWithIdempotencyKey, or belong to a
+// tree enabled with WithTreeCoalescing or WithTreeCache.
+func WithCoalescing() Option {
+	return func(a *API) {
+		a.coalescing()
+	}
+}
+
+// WithTreeCoalescing makes concurrent sync executions of treeID with the same
+// release and params share a single call to Builder, and so a single
+// session. Only enable it for trees whose response is a function of their
+// params.
+func WithTreeCoalescing(treeID string) Option {
+	return func(a *API) {
+		a.coalescing().trees[treeID] = true
+	}
+}
+
+// coalescing returns the flight group of the client, creating it if needed.
+func (a *API) coalescing() *flightGroup {
+	if a.flights == nil {
+		a.flights = &flightGroup{
+			calls: make(map[string]*flight),
+			trees: make(map[string]bool),
+		}
+	}
+
+	return a.flights
+}
+
+// flight is a call in progress shared by identical calls.
+type flight struct {
+	done     chan struct{}
+	response Response
+	err      error
+}
+
+type flightGroup struct {
+	trees map[string]bool
+
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+// join returns the flight of key and whether the caller must run it.
+func (g *flightGroup) join(key string) (*flight, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if f, ok := g.calls[key]; ok {
+		return f, false
+	}
+
+	f := &flight{done: make(chan struct{})}
+	g.calls[key] = f
+
+	return f, true
+}
+
+// run makes the call of the flight of key and lands it, even if it panics.
+func (g *flightGroup) run(key string, f *flight, call func() (Response, error)) {
+	f.err = errFlightPanicked
+	defer g.land(key, f)
+
+	f.response, f.err = call()
+}
+
+func (g *flightGroup) land(key string, f *flight) {
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+
+	close(f.done)
+}
+
+// coalescable reports whether identical calls of req may share a call.
+func (a *API) coalescable(req *Request) bool {
+	switch req.Operation {
+	case OperationSession:
+		return true
+	case OperationExecution:
+		if req.IdempotencyKey != "" || a.flights.trees[req.TreeID] {
+			return true
+		}
+
+		if a.responseCache != nil {
+			_, ok := a.responseCache.trees[req.TreeID]
+
+			return ok
+		}
+	}
+
+	return false
+}
+
+// coalesceMiddleware shares the calls of identical concurrent requests.
+func (a *API) coalesceMiddleware(next Handler) Handler {
+	return func(ctx context.Context, req *Request) (Response, error) {
+		if !a.coalescable(req) {
+			return next(ctx, req)
+		}
+
+		key, err := requestKey(a.TenantID, req)
+		if err != nil {
+			return next(ctx, req)
+		}
+
+		key += req.IdempotencyKey
+
+		f, leader := a.flights.join(key)
+		if leader {
+			a.flights.run(key, f, func() (Response, error) {
+				return next(ctx, req)
+			})
+
+			return copyResponse(f.response), f.err
+		}
+
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return Response{}, ctx.Err()
+		}
+
+		// the call was canceled by the context of another caller.
+		if errors.Is(f.err, context.Canceled) || errors.Is(f.err, context.DeadlineExceeded) {
+			return next(ctx, req)
+		}
+
+		response := copyResponse(f.response)
+		response.IdempotencyKey = req.IdempotencyKey
+
+		return response, f.err
+	}
+}
@@ -0,0 +1,268 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoalescing(t *testing.T) {
+	var calls int64
+
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&calls, 1)
+		<-release
+
+		w.Header().Set(headerSessionID, "session_1")
+
+		_, err := w.Write([]byte(`{"tree_version": "3", "response_type": "COMMON",
+			"data": {"vars": {"color": "red"}}}`))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v]", err)
+		}
+	}))
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL), WithCoalescing())
+
+	const callers = 5
+
+	var wg sync.WaitGroup
+
+	responses := make([]Response, callers)
+
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			response, err := client.GetSessionInformation("session_1")
+			if err != nil {
+				t.Error(err)
+			}
+
+			response.Data.Vars["color"] = i
+			responses[i] = response
+		}(i)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := atomic.LoadInt64(&calls); got != 1 {
+		t.Errorf("want [1] calls got [%d]", got)
+	}
+
+	for i, response := range responses {
+		if response.Data.Vars["color"] != i {
+			t.Errorf("want [%d] got [%v]", i, response.Data.Vars["color"])
+		}
+	}
+
+	if _, err := client.GetSessionInformation("session_1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := atomic.LoadInt64(&calls); got != 2 {
+		t.Errorf("want [2] calls got [%d]", got)
+	}
+}
+
+func TestCoalescingCanceledLeader(t *testing.T) {
+	var calls int64
+
+	stop := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&calls, 1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-stop:
+			}
+
+			return
+		}
+
+		_, err := w.Write([]byte(`{"tree_version": "3", "response_type": "COMMON"}`))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v]", err)
+		}
+	}))
+	defer server.Close()
+	defer close(stop)
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL), WithTreeCoalescing("tree_1"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	leaderDone := make(chan error, 1)
+
+	go func() {
+		_, err := client.AddExecutionContext(ctx, "tree_1", "release_1", nil)
+		leaderDone <- err
+	}()
+
+	for atomic.LoadInt64(&calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	followerDone := make(chan error, 1)
+
+	go func() {
+		_, err := client.AddExecution("tree_1", "release_1", nil)
+		followerDone <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	if err := <-leaderDone; err == nil {
+		t.Error("want the leader canceled")
+	}
+
+	if err := <-followerDone; err != nil {
+		t.Errorf("want the follower to call Builder itself got [%v]", err)
+	}
+}
+
+func TestCoalescingPanic(t *testing.T) {
+	client := New("aabbcc", "my_tenant_1312", WithCoalescing())
+
+	var calls int64
+
+	release := make(chan struct{})
+
+	handler := client.coalesceMiddleware(func(ctx context.Context, req *Request) (Response, error) {
+		if atomic.AddInt64(&calls, 1) == 1 {
+			<-release
+			panic("boom")
+		}
+
+		return Response{ResponseType: "COMMON"}, nil
+	})
+
+	req := &Request{Operation: OperationSession, SessionID: "session_1"}
+
+	leaderDone := make(chan interface{}, 1)
+
+	go func() {
+		defer func() {
+			leaderDone <- recover()
+		}()
+
+		_, _ = handler(context.Background(), req)
+	}()
+
+	for atomic.LoadInt64(&calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	followerDone := make(chan error, 1)
+
+	go func() {
+		_, err := handler(context.Background(), req)
+		followerDone <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	if recovered := <-leaderDone; recovered != "boom" {
+		t.Errorf("want the panic [boom] in the leader got [%v]", recovered)
+	}
+
+	select {
+	case err := <-followerDone:
+		if err != errFlightPanicked {
+			t.Errorf("want [%v] got [%v]", errFlightPanicked, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("want the follower released after the panic")
+	}
+
+	if _, err := handler(context.Background(), req); err != nil {
+		t.Errorf("want the next call made got [%v]", err)
+	}
+}
+
+func TestCoalescingExecutions(t *testing.T) {
+	var calls int64
+
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := atomic.AddInt64(&calls, 1)
+		<-release
+
+		w.Header().Set(headerSessionID, fmt.Sprintf("session_%d", call))
+
+		_, err := w.Write([]byte(`{"tree_version": "3", "response_type": "COMMON"}`))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v]", err)
+		}
+	}))
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL), WithCoalescing())
+
+	execute := func(ctx context.Context) []Response {
+		var wg sync.WaitGroup
+
+		responses := make([]Response, 2)
+
+		for i := range responses {
+			wg.Add(1)
+
+			go func(i int) {
+				defer wg.Done()
+
+				response, err := client.AddExecutionContext(ctx, "tree_1", "release_1", nil)
+				if err != nil {
+					t.Error(err)
+				}
+
+				responses[i] = response
+			}(i)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		release <- struct{}{}
+
+		if atomic.LoadInt64(&calls) == 2 {
+			release <- struct{}{}
+		}
+
+		wg.Wait()
+
+		return responses
+	}
+
+	responses := execute(context.Background())
+
+	if got := atomic.LoadInt64(&calls); got != 2 {
+		t.Errorf("want [2] calls for executions without idempotency key got [%d]", got)
+	}
+
+	if responses[0].SessionID == responses[1].SessionID {
+		t.Errorf("want distinct sessions got [%s] twice", responses[0].SessionID)
+	}
+
+	responses = execute(ContextWithIdempotencyKey(context.Background(), "order-1"))
+
+	if got := atomic.LoadInt64(&calls); got != 3 {
+		t.Errorf("want [3] calls got [%d]", got)
+	}
+
+	if responses[0].SessionID != responses[1].SessionID {
+		t.Errorf("want a shared session got [%s] and [%s]", responses[0].SessionID, responses[1].SessionID)
+	}
+}
@@ -0,0 +1,228 @@
+package builder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxBodySnippet is the number of bytes of a non JSON body kept in APIError.
+const maxBodySnippet = 512
+
+// Errors reported by Builder, an APIError wraps one of them so they can be
+// compared with errors.Is.
+var (
+	ErrBuilderAPI      = errors.New("internal_builder_error")
+	ErrTreeNotFound    = errors.New("tree_not_found")
+	ErrReleaseNotFound = errors.New("release_not_found")
+	ErrInvalidAPIKey   = errors.New("invalidApiKey")
+	ErrTenantNotFound  = errors.New("tenant_not_found")
+	ErrAPIKeyFormat    = errors.New("wrong_api_key_format")
+	ErrPermissions     = errors.New("not_enough_privileges")
+	ErrRateLimit       = errors.New("rate_limit_reached")
+)
+
+// APIError is the error returned when Builder answers with an error status.
+// Its message is the one of the wrapped sentinel, so existing comparisons
+// on err.Error() keep working.
+type APIError struct {
+	// StatusCode is the HTTP status of the response.
+	StatusCode int
+	// Code is the raw error reported by Builder, empty for balancer pages.
+	Code string
+	// Message is the optional description reported by Builder.
+	Message string
+	// RequestID is the value of the X-Request-Id header.
+	RequestID string
+	// TraceID is the value of the X-Trace-Id header, quote it when
+	// contacting support.
+	TraceID string
+	// Body is the beginning of the response body when it is not JSON.
+	Body string
+	// Err is one of the Err sentinels of the package.
+	Err error
+}
+
+func (e *APIError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+
+	// an APIError built by hand may have no sentinel.
+	if e.Code != "" {
+		return e.Code
+	}
+
+	return fmt.Sprintf("builder error: status %d", e.StatusCode)
+}
+
+// Unwrap returns the sentinel error wrapped by e.
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// IsNotFound reports whether err is caused by an unknown tenant, tree or release.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrTenantNotFound) ||
+		errors.Is(err, ErrTreeNotFound) ||
+		errors.Is(err, ErrReleaseNotFound)
+}
+
+// IsAuth reports whether err is caused by a wrong api key or missing permissions.
+func IsAuth(err error) bool {
+	return errors.Is(err, ErrInvalidAPIKey) ||
+		errors.Is(err, ErrAPIKeyFormat) ||
+		errors.Is(err, ErrPermissions)
+}
+
+// IsRateLimited reports whether err is caused by the Builder rate limit.
+func IsRateLimited(err error) bool {
+	return errors.Is(err, ErrRateLimit)
+}
+
+// IsRetryable reports whether the call that returned err may succeed if
+// repeated: network errors, timeouts of the http client, rate limits and
+// gateway errors. Errors of the context of the call are not retryable.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if IsRateLimited(err) {
+		return true
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return retryableStatus(apiErr.StatusCode)
+	}
+
+	return retryableError(err)
+}
+
+// retryableError reports whether err, returned by a call without response,
+// is a network error: a timeout of the http client, a failure to connect or a
+// connection closed early. Certificate, redirect and URL errors and the
+// errors of the context of the call are not.
+func retryableError(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		// the errors of the context are compared as is, the timeout of the
+		// http client also matches context.DeadlineExceeded with errors.Is.
+		if err == context.Canceled || err == context.DeadlineExceeded {
+			return false
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return true
+		}
+
+		switch e := err.(type) {
+		case *url.Error:
+			// its Timeout is the one of the error it wraps, checked next.
+		case *net.OpError:
+			return true
+		case net.Error:
+			if e.Timeout() {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func proc404(err string) error {
+	switch err {
+	case "function_not_found":
+		return ErrReleaseNotFound
+	case "tree_not_found":
+		return ErrTreeNotFound
+	}
+
+	return ErrBuilderAPI
+}
+
+func proc400(err string) error {
+	switch err {
+	case "authorization header format must be Bearer {token}":
+		return ErrAPIKeyFormat
+	case "tree_not_found":
+		return ErrTreeNotFound
+	}
+
+	return ErrBuilderAPI
+}
+
+func procBuilderErrors(status int, err string) error {
+	switch status {
+	case http.StatusNotFound:
+		return proc404(err)
+	case http.StatusUnauthorized:
+		return ErrInvalidAPIKey
+	case http.StatusForbidden:
+		return ErrPermissions
+	case http.StatusBadRequest:
+		return proc400(err)
+	case http.StatusTooManyRequests:
+		return ErrRateLimit
+	}
+
+	return ErrBuilderAPI
+}
+
+func procBalancerError(status int) error {
+	switch status {
+	case http.StatusNotFound:
+		return ErrTenantNotFound
+	case http.StatusServiceUnavailable, http.StatusTooManyRequests:
+		return ErrRateLimit
+	}
+
+	return ErrBuilderAPI
+}
+
+func bodySnippet(body []byte) string {
+	if len(body) > maxBodySnippet {
+		body = body[:maxBodySnippet]
+	}
+
+	return string(body)
+}
+
+func procErrors(response *http.Response, body []byte) error {
+	apiErr := &APIError{
+		StatusCode: response.StatusCode,
+		RequestID:  response.Header.Get(headerRequestID),
+		TraceID:    response.Header.Get(headerTraceID),
+	}
+
+	contentType := response.Header.Get("Content-Type")
+
+	if strings.Contains(contentType, "text/html") {
+		apiErr.Body = bodySnippet(body)
+		apiErr.Err = procBalancerError(response.StatusCode)
+
+		return apiErr
+	}
+
+	var res builderError
+
+	if err := json.Unmarshal(body, &res); err != nil {
+		apiErr.Body = bodySnippet(body)
+		apiErr.Err = ErrBuilderAPI
+
+		return apiErr
+	}
+
+	apiErr.Code = res.Error
+	apiErr.Message = res.Message
+	apiErr.Err = procBuilderErrors(response.StatusCode, res.Error)
+
+	return apiErr
+}
@@ -0,0 +1,166 @@
+package builder
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAPIError(t *testing.T) {
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		want        APIError
+	}{
+		{
+			"json error",
+			"application/json",
+			`{"error": "tree_not_found", "message": "tree 01GS8E0S does not exist"}`,
+			http.StatusNotFound,
+			APIError{
+				StatusCode: http.StatusNotFound,
+				Code:       "tree_not_found",
+				Message:    "tree 01GS8E0S does not exist",
+				Err:        ErrTreeNotFound,
+			},
+		},
+		{
+			"balancer page",
+			"text/html",
+			"<html>503 Service Temporarily Unavailable</html>",
+			http.StatusServiceUnavailable,
+			APIError{
+				StatusCode: http.StatusServiceUnavailable,
+				Body:       "<html>503 Service Temporarily Unavailable</html>",
+				Err:        ErrRateLimit,
+			},
+		},
+		{
+			"non json body",
+			"text/plain",
+			"upstream connect error",
+			http.StatusBadGateway,
+			APIError{
+				StatusCode: http.StatusBadGateway,
+				Body:       "upstream connect error",
+				Err:        ErrBuilderAPI,
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Header().Set(headerRequestID, "c563cd9a979c46c18d8d892b122f5e39")
+				w.Header().Set(headerTraceID, "c563cd9a979c46c18d8d892b122f5e40")
+				w.WriteHeader(tt.status)
+
+				n, err := w.Write([]byte(tt.body))
+				if err != nil {
+					t.Errorf("Error writing response httptest Server [%v][%d]", err, n)
+				}
+			}))
+
+			defer server.Close()
+
+			client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL))
+
+			_, err := client.AddExecution("01GS8E0S", "test", nil)
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("want *APIError got [%T]", err)
+			}
+
+			tt.want.RequestID = "c563cd9a979c46c18d8d892b122f5e39"
+			tt.want.TraceID = "c563cd9a979c46c18d8d892b122f5e40"
+
+			if diff := cmp.Diff(tt.want, *apiErr, cmp.Comparer(func(x, y error) bool {
+				return x == y
+			})); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+
+			if !errors.Is(err, tt.want.Err) {
+				t.Errorf("errors.Is(err, %v) must be true", tt.want.Err)
+			}
+		})
+	}
+}
+
+func TestAPIErrorWithoutSentinel(t *testing.T) {
+	cases := []struct {
+		err  *APIError
+		want string
+	}{
+		{&APIError{StatusCode: http.StatusNotFound, Code: "tree_not_found"}, "tree_not_found"},
+		{&APIError{StatusCode: http.StatusBadGateway}, "builder error: status 502"},
+	}
+
+	for _, tt := range cases {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("want [%s] got [%s]", tt.want, got)
+		}
+	}
+}
+
+func TestErrorClassification(t *testing.T) {
+	cases := []struct {
+		err         error
+		notFound    bool
+		auth        bool
+		rateLimited bool
+		retryable   bool
+	}{
+		{&APIError{StatusCode: http.StatusNotFound, Err: ErrTreeNotFound}, true, false, false, false},
+		{&APIError{StatusCode: http.StatusNotFound, Err: ErrTenantNotFound}, true, false, false, false},
+		{&APIError{StatusCode: http.StatusUnauthorized, Err: ErrInvalidAPIKey}, false, true, false, false},
+		{&APIError{StatusCode: http.StatusForbidden, Err: ErrPermissions}, false, true, false, false},
+		{&APIError{StatusCode: http.StatusServiceUnavailable, Err: ErrRateLimit}, false, false, true, true},
+		{&APIError{StatusCode: http.StatusBadGateway, Err: ErrBuilderAPI}, false, false, false, true},
+		{&APIError{StatusCode: http.StatusInternalServerError, Err: ErrBuilderAPI}, false, false, false, false},
+		{fmt.Errorf("wrapped: %w", &APIError{StatusCode: http.StatusGatewayTimeout, Err: ErrBuilderAPI}),
+			false, false, false, true},
+		{errors.New("other"), false, false, false, false},
+		{&url.Error{Op: "Get", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}},
+			false, false, false, true},
+		{&url.Error{Op: "Get", Err: io.EOF}, false, false, false, true},
+		{&url.Error{Op: "Get", Err: timeoutError{}}, false, false, false, true},
+		{&url.Error{Op: "Get", Err: context.DeadlineExceeded}, false, false, false, false},
+		{fmt.Errorf("wrapped: %w", context.Canceled), false, false, false, false},
+		{&url.Error{Op: "Get", Err: x509.UnknownAuthorityError{}}, false, false, false, false},
+		{&url.Error{Op: "Get", Err: errors.New("stopped after 10 redirects")}, false, false, false, false},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			if got := IsNotFound(tt.err); got != tt.notFound {
+				t.Errorf("IsNotFound want [%v] got [%v]", tt.notFound, got)
+			}
+
+			if got := IsAuth(tt.err); got != tt.auth {
+				t.Errorf("IsAuth want [%v] got [%v]", tt.auth, got)
+			}
+
+			if got := IsRateLimited(tt.err); got != tt.rateLimited {
+				t.Errorf("IsRateLimited want [%v] got [%v]", tt.rateLimited, got)
+			}
+
+			if got := IsRetryable(tt.err); got != tt.retryable {
+				t.Errorf("IsRetryable want [%v] got [%v]", tt.retryable, got)
+			}
+		})
+	}
+}
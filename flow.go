@@ -0,0 +1,216 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// defaultMaxSteps is the default number of interactions a Flow sends.
+	defaultMaxSteps = 20
+
+	// requestedVarsKey is the var listing the variables a tree waits for.
+	requestedVarsKey = "requested_vars"
+)
+
+var (
+	// ErrMaxSteps is returned when a flow reaches its maximum number of steps.
+	ErrMaxSteps = errors.New("max_steps_reached")
+	// ErrNoHandler is returned when a flow has no handler for a response.
+	ErrNoHandler = errors.New("no_handler")
+)
+
+// Answer is the interaction a StepHandler sends to the session.
+type Answer struct {
+	// Type of the interaction, InteractionTypeContinue when empty.
+	Type   string
+	Params map[string]interface{}
+}
+
+// StepHandler answers a response waiting for input.
+type StepHandler func(ctx context.Context, response Response) (Answer, error)
+
+// VarHandler returns the value of a variable requested by a tree.
+type VarHandler func(ctx context.Context, name string, response Response) (interface{}, error)
+
+// Transcript is the record of a flow run.
+type Transcript struct {
+	SessionID    string
+	Interactions []Interaction
+	// Response is the last response of the session.
+	Response Response
+}
+
+// Flow drives interactive sessions to completion: while the last response is
+// not final, the handler registered for its response type, or else the
+// handlers of the variables it requests, provide the next interaction.
+type Flow struct {
+	// MaxSteps is the maximum number of interactions after the execution, 20 by default.
+	MaxSteps int
+	// Timeout bounds the whole run when greater than zero.
+	Timeout time.Duration
+	// RequestedVars returns the variables a response waits for, by default
+	// the list in the requested_vars var.
+	RequestedVars func(response Response) []string
+
+	handlers    map[string]StepHandler
+	varHandlers map[string]VarHandler
+}
+
+// NewFlow creates an empty Flow, the zero Flow is ready to use too.
+func NewFlow() *Flow {
+	return &Flow{
+		MaxSteps:      defaultMaxSteps,
+		RequestedVars: requestedVars,
+		handlers:      make(map[string]StepHandler),
+		varHandlers:   make(map[string]VarHandler),
+	}
+}
+
+// Handle registers the handler of a response type.
+func (f *Flow) Handle(responseType string, handler StepHandler) *Flow {
+	if f.handlers == nil {
+		f.handlers = make(map[string]StepHandler)
+	}
+
+	f.handlers[responseType] = handler
+
+	return f
+}
+
+// HandleVar registers the handler of a requested variable.
+func (f *Flow) HandleVar(name string, handler VarHandler) *Flow {
+	if f.varHandlers == nil {
+		f.varHandlers = make(map[string]VarHandler)
+	}
+
+	f.varHandlers[name] = handler
+
+	return f
+}
+
+// Run starts a session on the tree and drives it to completion.
+func (f *Flow) Run(ctx context.Context, client ContextClient, treeID, releaseID string,
+	params map[string]interface{}) (Transcript, error) {
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
+
+	session, err := StartSession(ctx, client, treeID, releaseID, params)
+	if err != nil {
+		return Transcript{}, err
+	}
+
+	return f.drive(ctx, session)
+}
+
+// Drive drives an existing session to completion.
+func (f *Flow) Drive(ctx context.Context, session *Session) (Transcript, error) {
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
+
+	return f.drive(ctx, session)
+}
+
+func (f *Flow) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if f.Timeout > 0 {
+		return context.WithTimeout(ctx, f.Timeout)
+	}
+
+	return context.WithCancel(ctx)
+}
+
+func (f *Flow) drive(ctx context.Context, session *Session) (Transcript, error) {
+	maxSteps := f.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = defaultMaxSteps
+	}
+
+	// a resumed session is waiting for an unknown step.
+	if !session.isLoaded() {
+		if _, err := session.Refresh(ctx); err != nil {
+			return transcript(session), err
+		}
+	}
+
+	for step := 0; !session.Done(); step++ {
+		if step >= maxSteps {
+			return transcript(session), fmt.Errorf("%w: %d", ErrMaxSteps, maxSteps)
+		}
+
+		answer, err := f.answer(ctx, session.Last())
+		if err != nil {
+			return transcript(session), err
+		}
+
+		if answer.Type == "" {
+			answer.Type = InteractionTypeContinue
+		}
+
+		if _, err := session.Continue(ctx, answer.Type, answer.Params); err != nil {
+			return transcript(session), err
+		}
+	}
+
+	return transcript(session), nil
+}
+
+func (f *Flow) answer(ctx context.Context, response Response) (Answer, error) {
+	if handler, ok := f.handlers[response.ResponseType]; ok {
+		return handler(ctx, response)
+	}
+
+	requested := requestedVars
+	if f.RequestedVars != nil {
+		requested = f.RequestedVars
+	}
+
+	names := requested(response)
+	if len(names) == 0 {
+		return Answer{}, fmt.Errorf("%w: response type %s", ErrNoHandler, response.ResponseType)
+	}
+
+	params := make(map[string]interface{}, len(names))
+
+	for _, name := range names {
+		handler, ok := f.varHandlers[name]
+		if !ok {
+			return Answer{}, fmt.Errorf("%w: var %s", ErrNoHandler, name)
+		}
+
+		value, err := handler(ctx, name, response)
+		if err != nil {
+			return Answer{}, err
+		}
+
+		params[name] = value
+	}
+
+	return Answer{Params: params}, nil
+}
+
+func requestedVars(response Response) []string {
+	list, ok := response.Data.Vars[requestedVarsKey].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	names := make([]string, 0, len(list))
+
+	for _, item := range list {
+		if name, ok := item.(string); ok {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+func transcript(session *Session) Transcript {
+	return Transcript{
+		SessionID:    session.ID(),
+		Interactions: session.History(),
+		Response:     session.Last(),
+	}
+}
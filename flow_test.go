@@ -0,0 +1,184 @@
+package builder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newFlowServer answers executions asking for the size, then asks for a
+// confirmation and ends the session once confirmed.
+func newFlowServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var requestBody struct {
+			Parameters      map[string]interface{} `json:"parameters"`
+			InteractionType string                 `json:"type"`
+		}
+
+		err := json.NewDecoder(r.Body).Decode(&requestBody)
+		if err != nil {
+			t.Errorf("Error request body %v", err)
+		}
+
+		body := `{"response_type": "COMMON", "data": {"vars": {"result": "done"}}}`
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/executions"):
+			body = `{"response_type": "INPUT", "data": {"vars": {"requested_vars": ["size"]}}}`
+		case requestBody.InteractionType == "continue":
+			if requestBody.Parameters["size"] != "L" {
+				t.Errorf("want size [L] got [%v]", requestBody.Parameters["size"])
+			}
+
+			body = `{"response_type": "CONFIRM", "data": {}}`
+		case requestBody.InteractionType != "confirm":
+			t.Errorf("want interaction type [confirm] got [%s]", requestBody.InteractionType)
+		}
+
+		w.Header().Set(headerSessionID, "c563cd9a979c46c18d8d892b122f5e38")
+
+		n, err := w.Write([]byte(body))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v][%d]", err, n)
+		}
+	}))
+}
+
+func TestFlowRun(t *testing.T) {
+	server := newFlowServer(t)
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL))
+
+	flow := NewFlow().
+		HandleVar("size", func(ctx context.Context, name string, response Response) (interface{}, error) {
+			return "L", nil
+		}).
+		Handle("CONFIRM", func(ctx context.Context, response Response) (Answer, error) {
+			return Answer{Type: "confirm"}, nil
+		})
+
+	transcript, err := flow.Run(context.Background(), client, "color_pick", "production", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(transcript.Interactions) != 3 {
+		t.Fatalf("want [3] interactions got [%d]", len(transcript.Interactions))
+	}
+
+	if transcript.Response.Data.Vars["result"] != "done" {
+		t.Errorf("want result [done] got [%v]", transcript.Response.Data.Vars["result"])
+	}
+
+	if transcript.SessionID != "c563cd9a979c46c18d8d892b122f5e38" {
+		t.Errorf("want session id [c563cd9a979c46c18d8d892b122f5e38] got [%s]", transcript.SessionID)
+	}
+}
+
+func TestFlowLiteral(t *testing.T) {
+	server := newFlowServer(t)
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL))
+
+	flow := &Flow{MaxSteps: 5}
+	flow.
+		HandleVar("size", func(ctx context.Context, name string, response Response) (interface{}, error) {
+			return "L", nil
+		}).
+		Handle("CONFIRM", func(ctx context.Context, response Response) (Answer, error) {
+			return Answer{Type: "confirm"}, nil
+		})
+
+	transcript, err := flow.Run(context.Background(), client, "color_pick", "production", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if transcript.Response.Data.Vars["result"] != "done" {
+		t.Errorf("want result [done] got [%v]", transcript.Response.Data.Vars["result"])
+	}
+}
+
+func TestFlowErrors(t *testing.T) {
+	server := newFlowServer(t)
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL))
+
+	transcript, err := NewFlow().Run(context.Background(), client, "color_pick", "production", nil)
+	if !errors.Is(err, ErrNoHandler) {
+		t.Errorf("want [%v] got [%v]", ErrNoHandler, err)
+	}
+
+	if len(transcript.Interactions) != 1 {
+		t.Errorf("want the partial transcript, got [%d] interactions", len(transcript.Interactions))
+	}
+
+	flow := NewFlow().Handle("INPUT", func(ctx context.Context, response Response) (Answer, error) {
+		return Answer{Params: map[string]interface{}{"size": "L"}}, nil
+	})
+	flow.MaxSteps = 1
+
+	_, err = flow.Run(context.Background(), client, "color_pick", "production", nil)
+	if !errors.Is(err, ErrMaxSteps) {
+		t.Errorf("want [%v] got [%v]", ErrMaxSteps, err)
+	}
+
+	flow = NewFlow().HandleVar("size", func(ctx context.Context, name string, response Response) (interface{}, error) {
+		<-ctx.Done()
+
+		return nil, ctx.Err()
+	})
+	flow.Timeout = 10 * time.Millisecond
+
+	_, err = flow.Run(context.Background(), client, "color_pick", "production", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want [%v] got [%v]", context.DeadlineExceeded, err)
+	}
+}
+
+func TestFlowDriveResumed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := `{"response_type": "COMMON", "data": {"vars": {"result": "done"}}}`
+		if r.Method == http.MethodGet {
+			body = `{"response_type": "CONFIRM", "data": {}}`
+		}
+
+		w.Header().Set(headerSessionID, "c563cd9a979c46c18d8d892b122f5e38")
+
+		n, err := w.Write([]byte(body))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v][%d]", err, n)
+		}
+	}))
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL))
+	session := ResumeSession(client, "c563cd9a979c46c18d8d892b122f5e38")
+
+	if session.Done() {
+		t.Fatal("want a resumed session not done before its state is known")
+	}
+
+	flow := NewFlow().Handle("CONFIRM", func(ctx context.Context, response Response) (Answer, error) {
+		return Answer{Type: "confirm"}, nil
+	})
+
+	transcript, err := flow.Drive(context.Background(), session)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(transcript.Interactions) != 1 || transcript.Response.Data.Vars["result"] != "done" {
+		t.Errorf("want the confirmation sent got [%+v]", transcript)
+	}
+}
@@ -8,13 +8,25 @@ import (
 
 // GetSessionInformation adds an interaction for a session.
 func (a *API) GetSessionInformation(sessionID string) (Response, error) {
+	return a.GetSessionInformationContext(context.Background(), sessionID)
+}
+
+// GetSessionInformationContext gets the information of a session using the given context.
+func (a *API) GetSessionInformationContext(ctx context.Context, sessionID string) (Response, error) {
+	return a.call(ctx, &Request{
+		Operation: OperationSession,
+		SessionID: sessionID,
+	})
+}
+
+func (a *API) sessionRequest(ctx context.Context, req *Request) (*http.Request, error) {
 	baseURL := fmt.Sprintf("%s/v2/tenants/%s/executions/%s",
-		a.apiURL, a.TenantID, sessionID)
+		a.apiURL, a.TenantID, req.SessionID)
 
-	request, err := http.NewRequestWithContext(context.TODO(), http.MethodGet, baseURL, nil)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL, nil)
 	if err != nil {
-		return Response{}, fmt.Errorf("%w", err)
+		return nil, fmt.Errorf("%w", err)
 	}
 
-	return a.builderBaseSyncRequest(context.TODO(), request)
+	return request, nil
 }
@@ -66,6 +66,7 @@ func TestGetSessionInfo(t *testing.T) {
 	payloadResponse := Response{
 		SessionID:    "c563cd9a979c46c18d8d892b122f5e38",
 		RequestID:    "c563cd9a979c46c18d8d892b122f5e39",
+		TraceID:      "c563cd9a979c46c18d8d892b122f5e40",
 		TreeVersion:  "3",
 		ResponseType: "COMMON",
 		Data: ResponseData{
@@ -0,0 +1,57 @@
+package builder
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// idempotencyError wraps the error of a call made with an idempotency key.
+type idempotencyError struct {
+	key string
+	err error
+}
+
+func (e *idempotencyError) Error() string {
+	return e.err.Error()
+}
+
+func (e *idempotencyError) Unwrap() error {
+	return e.err
+}
+
+// IdempotencyKeyFromError returns the idempotency key of the failed call
+// behind err, empty when it had none. Retrying the call with the same key,
+// see ContextWithIdempotencyKey, keeps Builder from processing it twice.
+func IdempotencyKeyFromError(err error) string {
+	var keyErr *idempotencyError
+	if errors.As(err, &keyErr) {
+		return keyErr.key
+	}
+
+	return ""
+}
+
+// WithIdempotencyKeys generates an idempotency key for every execution
+// whose context carries none, so executions are also retried by the retry
+// policy. The key is reported on Response.IdempotencyKey, and for failed
+// calls by IdempotencyKeyFromError.
+func WithIdempotencyKeys() Option {
+	return func(a *API) {
+		a.idempotencyKeys = true
+	}
+}
+
+// newIdempotencyKey returns a random UUID.
+func newIdempotencyKey() (string, error) {
+	var b [16]byte
+
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("%w", err)
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
@@ -0,0 +1,197 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestGeneratedIdempotencyKeys(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get(headerIdempotencyKey))
+		calls := len(keys)
+		mu.Unlock()
+
+		if r.Method == http.MethodPost && calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		w.Header().Set(headerRequestID, "request_1")
+
+		_, err := w.Write([]byte(`{"tree_version": "3", "response_type": "COMMON"}`))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v]", err)
+		}
+	}))
+	defer server.Close()
+
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Multiplier: 1}
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL),
+		WithIdempotencyKeys(), WithRetryPolicy(policy))
+
+	response, err := client.AddExecution("tree_1", "release_1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !uuidPattern.MatchString(response.IdempotencyKey) {
+		t.Errorf("want a random UUID got [%s]", response.IdempotencyKey)
+	}
+
+	if len(keys) != 2 || keys[0] != response.IdempotencyKey || keys[1] != response.IdempotencyKey {
+		t.Errorf("want [%s] sent on every attempt got %v", response.IdempotencyKey, keys)
+	}
+
+	other, err := client.AddExecution("tree_1", "release_1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if other.IdempotencyKey == response.IdempotencyKey {
+		t.Errorf("want a new key per call got [%s] twice", other.IdempotencyKey)
+	}
+
+	session, err := client.GetSessionInformation("session_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if session.IdempotencyKey != "" || keys[len(keys)-1] != "" {
+		t.Errorf("session lookups must not carry generated keys, got [%s]", session.IdempotencyKey)
+	}
+}
+
+func TestCallerIdempotencyKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key := r.Header.Get(headerIdempotencyKey); key != "order-1" {
+			t.Errorf("want [%s] got [%s]", "order-1", key)
+		}
+
+		_, err := w.Write([]byte(`{"tree_version": "3", "response_type": "COMMON"}`))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v]", err)
+		}
+	}))
+	defer server.Close()
+
+	var seen string
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL), WithIdempotencyKeys())
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (Response, error) {
+			seen = req.IdempotencyKey
+
+			return next(ctx, req)
+		}
+	})
+
+	ctx := ContextWithIdempotencyKey(context.Background(), "order-1")
+
+	response, err := client.AddExecutionContext(ctx, "tree_1", "release_1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.IdempotencyKey != "order-1" || seen != "order-1" {
+		t.Errorf("want [%s] got [%s] and [%s] in middleware", "order-1", response.IdempotencyKey, seen)
+	}
+}
+
+func TestCachedIdempotencyKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(`{"tree_version": "3", "response_type": "COMMON"}`))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v]", err)
+		}
+	}))
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL), WithIdempotencyKeys(),
+		WithTreeCache("tree_1", CachePolicy{TTL: time.Minute}))
+
+	first, err := client.AddExecution("tree_1", "release_1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := ContextWithIdempotencyKey(context.Background(), "mine")
+
+	cached, err := client.AddExecutionContext(ctx, "tree_1", "release_1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cached.IdempotencyKey != "mine" {
+		t.Errorf("want [%s] got [%s], key of the first call [%s]", "mine", cached.IdempotencyKey, first.IdempotencyKey)
+	}
+}
+
+func TestIdempotencyKeyOnFailure(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get(headerIdempotencyKey))
+		calls := len(keys)
+		mu.Unlock()
+
+		if calls == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(200 * time.Millisecond):
+			}
+
+			return
+		}
+
+		w.Header().Set(headerRequestID, "request_1")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL), WithIdempotencyKeys(),
+		WithTimeout(20*time.Millisecond))
+
+	_, err := client.AddAsyncExecution("tree_1", "release_1", nil)
+	if err == nil {
+		t.Fatal("want a timeout")
+	}
+
+	key := IdempotencyKeyFromError(err)
+	if !uuidPattern.MatchString(key) {
+		t.Fatalf("want the generated key got [%s] from [%v]", key, err)
+	}
+
+	ctx := ContextWithIdempotencyKey(context.Background(), key)
+
+	response, err := client.AddAsyncExecutionResponseContext(ctx, "tree_1", "release_1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.RequestID != "request_1" || response.IdempotencyKey != key || keys[1] != key {
+		t.Errorf("want [%s] reused got [%s] and header [%s]", key, response.IdempotencyKey, keys[1])
+	}
+
+	if key := IdempotencyKeyFromError(errors.New("other")); key != "" {
+		t.Errorf("want no key got [%s]", key)
+	}
+}
@@ -0,0 +1,170 @@
+package builder
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RateLimit configures a token bucket allowing Rate requests per second with
+// bursts of up to Burst requests.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitStats reports the time calls spent waiting on the client rate limiter.
+type RateLimitStats struct {
+	// Waits is the number of calls that had to wait for a token.
+	Waits int64
+	// WaitTime is the total time spent waiting.
+	WaitTime time.Duration
+}
+
+// WithRateLimit throttles every call made by the client.
+func WithRateLimit(limit RateLimit) Option {
+	return func(a *API) {
+		a.limiter.global = newTokenBucket(limit)
+	}
+}
+
+// WithTreeRateLimit throttles the executions of treeID, in addition to the
+// limit set with WithRateLimit.
+func WithTreeRateLimit(treeID string, limit RateLimit) Option {
+	return func(a *API) {
+		if a.limiter.trees == nil {
+			a.limiter.trees = make(map[string]*tokenBucket)
+		}
+
+		a.limiter.trees[treeID] = newTokenBucket(limit)
+	}
+}
+
+// RateLimitStats returns the statistics of the client rate limiter.
+func (a *API) RateLimitStats() RateLimitStats {
+	return RateLimitStats{
+		Waits:    atomic.LoadInt64(&a.limiter.waits),
+		WaitTime: time.Duration(atomic.LoadInt64(&a.limiter.waitTime)),
+	}
+}
+
+// rateLimiter holds the buckets configured with options, they are not
+// modified after New.
+type rateLimiter struct {
+	waits    int64
+	waitTime int64
+
+	global *tokenBucket
+	trees  map[string]*tokenBucket
+}
+
+// wait blocks until the global and tree buckets allow a new request.
+func (l *rateLimiter) wait(ctx context.Context, treeID string) error {
+	start := time.Now()
+	waited := false
+
+	var taken []*tokenBucket
+
+	for _, bucket := range []*tokenBucket{l.global, l.trees[treeID]} {
+		if bucket == nil {
+			continue
+		}
+
+		ok, err := bucket.wait(ctx)
+		if err != nil {
+			// the request is not sent, give back the tokens already taken.
+			for _, b := range taken {
+				b.release()
+			}
+
+			return err
+		}
+
+		taken = append(taken, bucket)
+		waited = waited || ok
+	}
+
+	if waited {
+		atomic.AddInt64(&l.waits, 1)
+		atomic.AddInt64(&l.waitTime, int64(time.Since(start)))
+	}
+
+	return nil
+}
+
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit RateLimit) *tokenBucket {
+	if limit.Rate <= 0 {
+		return nil
+	}
+
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &tokenBucket{
+		rate:   limit.Rate,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// reserve takes a token and returns how long the caller must wait before using it.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+
+	b.last = now
+	b.tokens--
+
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// release gives back a token reserved but not used.
+func (b *tokenBucket) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens++
+}
+
+// wait blocks until a token is available, reports whether the caller had to wait.
+func (b *tokenBucket) wait(ctx context.Context) (bool, error) {
+	delay := b.reserve(time.Now())
+	if delay == 0 {
+		return false, nil
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		b.release()
+
+		return false, context.DeadlineExceeded
+	}
+
+	if err := sleep(ctx, delay); err != nil {
+		b.release()
+
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,120 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketReserve(t *testing.T) {
+	bucket := newTokenBucket(RateLimit{Rate: 10, Burst: 2})
+	now := bucket.last
+
+	for i := 0; i < 2; i++ {
+		if wait := bucket.reserve(now); wait != 0 {
+			t.Errorf("burst token [%d] want no wait got [%v]", i, wait)
+		}
+	}
+
+	if wait := bucket.reserve(now); wait != 100*time.Millisecond {
+		t.Errorf("want [100ms] got [%v]", wait)
+	}
+
+	if wait := bucket.reserve(now.Add(100 * time.Millisecond)); wait != 100*time.Millisecond {
+		t.Errorf("want [100ms] got [%v]", wait)
+	}
+
+	if bucket := newTokenBucket(RateLimit{}); bucket != nil {
+		t.Error("a zero rate must disable the bucket")
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, err := w.Write([]byte(`{"tree_version": "3", "response_type": "COMMON"}`))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v][%d]", err, n)
+		}
+	}))
+
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL),
+		WithTreeRateLimit("color_pick", RateLimit{Rate: 20, Burst: 1}))
+
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.AddExecution("color_pick", "production", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("3 calls at 20 rps must take at least 100ms, took [%v]", elapsed)
+	}
+
+	if _, err := client.AddExecution("other_tree", "production", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := client.RateLimitStats()
+	if stats.Waits != 2 {
+		t.Errorf("want [2] waits got [%d]", stats.Waits)
+	}
+
+	if stats.WaitTime < 90*time.Millisecond {
+		t.Errorf("want at least [100ms] of wait time got [%v]", stats.WaitTime)
+	}
+}
+
+func TestRateLimitContextDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, err := w.Write([]byte(`{"tree_version": "3", "response_type": "COMMON"}`))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v][%d]", err, n)
+		}
+	}))
+
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL),
+		WithRateLimit(RateLimit{Rate: 1, Burst: 1}))
+
+	if _, err := client.GetSessionInformation("c563cd9a979c46c18d8d892b122f5e38"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := client.GetSessionInformationContext(ctx, "c563cd9a979c46c18d8d892b122f5e38")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want [%v] got [%v]", context.DeadlineExceeded, err)
+	}
+}
+
+func TestRateLimiterReleasesGlobalToken(t *testing.T) {
+	limiter := rateLimiter{
+		global: newTokenBucket(RateLimit{Rate: 1, Burst: 2}),
+		trees:  map[string]*tokenBucket{"tree_1": newTokenBucket(RateLimit{Rate: 1, Burst: 1})},
+	}
+
+	if err := limiter.wait(context.Background(), "tree_1"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := limiter.wait(ctx, "tree_1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want [%v] got [%v]", context.DeadlineExceeded, err)
+	}
+
+	if wait := limiter.global.reserve(time.Now()); wait != 0 {
+		t.Errorf("want the global token given back got a wait of [%v]", wait)
+	}
+}
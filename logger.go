@@ -0,0 +1,131 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// redacted replaces the logged values of secrets.
+const redacted = "REDACTED"
+
+// Logger receives the structured events of the client as a message and
+// alternating keys and values. *slog.Logger implements it.
+type Logger interface {
+	DebugContext(ctx context.Context, msg string, args ...interface{})
+	ErrorContext(ctx context.Context, msg string, args ...interface{})
+}
+
+// WithLogger logs a debug event for every request sent to Builder, nothing
+// is logged by default. The Authorization header is always redacted.
+func WithLogger(logger Logger) Option {
+	return func(a *API) {
+		a.logger = logger
+	}
+}
+
+// WithRedactedParams redacts the given params from the logged requests, also
+// the keys of nested maps, including maps inside lists.
+func WithRedactedParams(names ...string) Option {
+	return func(a *API) {
+		if a.redactedParams == nil {
+			a.redactedParams = make(map[string]bool, len(names))
+		}
+
+		for _, name := range names {
+			a.redactedParams[name] = true
+		}
+	}
+}
+
+// NewStdLogger adapts a log.Logger, events are printed as the message
+// followed by key=value pairs.
+func NewStdLogger(logger *log.Logger) Logger {
+	return stdLogger{logger: logger}
+}
+
+type stdLogger struct {
+	logger *log.Logger
+}
+
+func (l stdLogger) DebugContext(ctx context.Context, msg string, args ...interface{}) {
+	l.print("DEBUG", msg, args)
+}
+
+func (l stdLogger) ErrorContext(ctx context.Context, msg string, args ...interface{}) {
+	l.print("ERROR", msg, args)
+}
+
+func (l stdLogger) print(level, msg string, args []interface{}) {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s %s", level, msg)
+
+	for i := 0; i+1 < len(args); i += 2 {
+		fmt.Fprintf(&b, " %v=%v", args[i], args[i+1])
+	}
+
+	l.logger.Print(b.String())
+}
+
+// logAttempt logs a single attempt of request.
+func (a *API) logAttempt(ctx context.Context, req *Request, request *http.Request,
+	attempt int, response *http.Response, duration time.Duration, err error) {
+	if a.logger == nil {
+		return
+	}
+
+	args := []interface{}{
+		"method", request.Method,
+		"path", request.URL.Path,
+		"attempt", attempt,
+		"duration", duration,
+		"headers", a.redactHeaders(request.Header),
+	}
+
+	if req.Operation != "" {
+		args = append(args, "operation", string(req.Operation))
+	}
+
+	if req.Params != nil {
+		args = append(args, "params", a.redactParams(req.Params))
+	}
+
+	if response != nil {
+		args = append(args,
+			"status", response.StatusCode,
+			"session_id", response.Header.Get(headerSessionID),
+			"request_id", response.Header.Get(headerRequestID),
+			"trace_id", response.Header.Get(headerTraceID),
+		)
+	}
+
+	if err != nil {
+		args = append(args, "error", err)
+	}
+
+	a.logger.DebugContext(ctx, "builder request", args...)
+}
+
+func (a *API) redactHeaders(header http.Header) http.Header {
+	header = header.Clone()
+
+	if header.Get("Authorization") != "" {
+		header.Set("Authorization", redacted)
+	}
+
+	return header
+}
+
+func (a *API) redactParams(params map[string]interface{}) map[string]interface{} {
+	if len(a.redactedParams) == 0 {
+		return params
+	}
+
+	copied, _ := copyRedacted(params, a.redactedParams).(map[string]interface{})
+
+	return copied
+}
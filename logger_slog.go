@@ -0,0 +1,20 @@
+//go:build go1.21
+// +build go1.21
+
+package builder
+
+import (
+	"log/slog"
+)
+
+var _ Logger = (*slog.Logger)(nil)
+
+// NewSlogLogger adapts a slog.Logger, the default logger is used when
+// logger is nil.
+func NewSlogLogger(logger *slog.Logger) Logger {
+	if logger == nil {
+		return slog.Default()
+	}
+
+	return logger
+}
@@ -0,0 +1,52 @@
+//go:build go1.21
+// +build go1.21
+
+package builder
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSlogLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(`{"tree_version": "3", "response_type": "COMMON"}`))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v]", err)
+		}
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL),
+		WithLogger(NewSlogLogger(slog.New(handler))))
+
+	if _, err := client.GetSessionInformation("session_1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(buf.String(), "aabbcc") {
+		t.Errorf("api key must not be logged [%s]", buf.String())
+	}
+
+	var event struct {
+		Msg    string `json:"msg"`
+		Method string `json:"method"`
+		Status int    `json:"status"`
+	}
+
+	if err := json.Unmarshal(buf.Bytes(), &event); err != nil {
+		t.Fatal(err)
+	}
+
+	if event.Msg != "builder request" || event.Method != http.MethodGet || event.Status != http.StatusOK {
+		t.Errorf("unexpected event [%s]", buf.String())
+	}
+}
@@ -0,0 +1,125 @@
+package builder
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type logEvent struct {
+	msg    string
+	fields map[string]interface{}
+}
+
+type fakeLogger struct {
+	mu     sync.Mutex
+	events []logEvent
+}
+
+func (l *fakeLogger) DebugContext(ctx context.Context, msg string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	fields := make(map[string]interface{})
+	for i := 0; i+1 < len(args); i += 2 {
+		fields[args[i].(string)] = args[i+1]
+	}
+
+	l.events = append(l.events, logEvent{msg: msg, fields: fields})
+}
+
+func (l *fakeLogger) ErrorContext(ctx context.Context, msg string, args ...interface{}) {
+	l.DebugContext(ctx, msg, args...)
+}
+
+func TestLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerSessionID, "session_1")
+		w.Header().Set(headerRequestID, "request_1")
+
+		_, err := w.Write([]byte(`{"tree_version": "3", "response_type": "COMMON"}`))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v]", err)
+		}
+	}))
+	defer server.Close()
+
+	logger := &fakeLogger{}
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL),
+		WithLogger(logger), WithRedactedParams("ssn"))
+
+	params := map[string]interface{}{
+		"ssn": "123-45-6789",
+		"age": 30,
+		"family": []interface{}{
+			map[string]interface{}{"name": "Ana", "ssn": "987-65-4321"},
+		},
+	}
+
+	if _, err := client.AddExecution("tree_1", "release_1", params); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(logger.events) != 1 {
+		t.Fatalf("want [1] events got [%d]", len(logger.events))
+	}
+
+	fields := logger.events[0].fields
+
+	wantPath := "/v2/tenants/my_tenant_1312/trees/tree_1/releases/release_1/executions"
+	if fields["path"] != wantPath {
+		t.Errorf("want [%s] got [%v]", wantPath, fields["path"])
+	}
+
+	if fields["status"] != http.StatusOK {
+		t.Errorf("want [%d] got [%v]", http.StatusOK, fields["status"])
+	}
+
+	if fields["session_id"] != "session_1" || fields["request_id"] != "request_1" {
+		t.Errorf("want [session_1 request_1] got [%v %v]", fields["session_id"], fields["request_id"])
+	}
+
+	if got := fields["headers"].(http.Header).Get("Authorization"); got != redacted {
+		t.Errorf("want [%s] got [%s]", redacted, got)
+	}
+
+	logged := fields["params"].(map[string]interface{})
+	if logged["ssn"] != redacted || logged["age"] != 30 {
+		t.Errorf("want ssn redacted got [%v]", logged)
+	}
+
+	member := logged["family"].([]interface{})[0].(map[string]interface{})
+	if member["ssn"] != redacted || member["name"] != "Ana" {
+		t.Errorf("want nested ssn redacted got [%v]", member)
+	}
+
+	original := params["family"].([]interface{})[0].(map[string]interface{})
+	if original["ssn"] != "987-65-4321" {
+		t.Errorf("nested params of the caller must not be modified, got [%v]", original["ssn"])
+	}
+
+	if params["ssn"] != "123-45-6789" {
+		t.Errorf("params of the caller must not be modified, got [%v]", params["ssn"])
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger := NewStdLogger(log.New(&buf, "", 0))
+	logger.DebugContext(context.Background(), "builder request", "status", 200, "path", "/v2")
+
+	want := "DEBUG builder request status=200 path=/v2\n"
+	if buf.String() != want {
+		t.Errorf("want [%s] got [%s]", want, buf.String())
+	}
+
+	if strings.Contains(buf.String(), "aabbcc") {
+		t.Error("api key must not be logged")
+	}
+}
@@ -0,0 +1,127 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// Error kinds reported to a MetricsRecorder besides the text of the Builder
+// sentinels, such as tree_not_found or rate_limit_reached.
+const (
+	ErrorKindTimeout  = "timeout"
+	ErrorKindCanceled = "canceled"
+	ErrorKindNetwork  = "network"
+	ErrorKindOther    = "other"
+)
+
+// RequestMetric describes a finished call of the client.
+type RequestMetric struct {
+	Operation Operation
+	TreeID    string
+	ReleaseID string
+	// StatusCode is the HTTP status of the last attempt, 0 when Builder
+	// could not be reached.
+	StatusCode int
+	// ErrorKind is empty when the call succeeded, see ErrorKind.
+	ErrorKind string
+	// Retries is the number of attempts after the first one.
+	Retries  int
+	Duration time.Duration
+}
+
+// MetricsRecorder receives the metrics of the client, see the
+// prombuilder module for a Prometheus implementation. It must be safe for
+// concurrent use.
+type MetricsRecorder interface {
+	// AddInFlight adds delta to the calls of operation in progress.
+	AddInFlight(operation Operation, delta int)
+	// ObserveRequest records a finished call.
+	ObserveRequest(metric RequestMetric)
+}
+
+// WithMetrics reports the metrics of every call to recorder.
+func WithMetrics(recorder MetricsRecorder) Option {
+	return func(a *API) {
+		a.metrics = recorder
+	}
+}
+
+// ErrorKind classifies err for metrics: the text of the wrapped Builder
+// sentinel for an APIError, or one of the ErrorKind constants.
+func ErrorKind(err error) string {
+	var apiErr *APIError
+
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &apiErr) && apiErr.Err != nil:
+		return apiErr.Err.Error()
+	case errors.Is(err, ErrCircuitOpen):
+		return ErrCircuitOpen.Error()
+	case errors.Is(err, context.Canceled):
+		return ErrorKindCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorKindTimeout
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrorKindTimeout
+		}
+
+		return ErrorKindNetwork
+	}
+
+	return ErrorKindOther
+}
+
+// callAttempts is updated by do with the attempts of a call.
+type callAttempts struct {
+	count      int
+	statusCode int
+}
+
+type attemptsKey struct{}
+
+func contextWithAttempts(ctx context.Context, a *callAttempts) context.Context {
+	return context.WithValue(ctx, attemptsKey{}, a)
+}
+
+func attemptsFromContext(ctx context.Context) *callAttempts {
+	a, _ := ctx.Value(attemptsKey{}).(*callAttempts)
+
+	return a
+}
+
+// metricsMiddleware reports every call to the metrics recorder.
+func (a *API) metricsMiddleware(next Handler) Handler {
+	return func(ctx context.Context, req *Request) (Response, error) {
+		a.metrics.AddInFlight(req.Operation, 1)
+		defer a.metrics.AddInFlight(req.Operation, -1)
+
+		tries := &callAttempts{}
+		start := time.Now()
+
+		response, err := next(contextWithAttempts(ctx, tries), req)
+
+		metric := RequestMetric{
+			Operation:  req.Operation,
+			TreeID:     req.TreeID,
+			ReleaseID:  req.ReleaseID,
+			StatusCode: tries.statusCode,
+			ErrorKind:  ErrorKind(err),
+			Duration:   time.Since(start),
+		}
+
+		if tries.count > 1 {
+			metric.Retries = tries.count - 1
+		}
+
+		a.metrics.ObserveRequest(metric)
+
+		return response, err
+	}
+}
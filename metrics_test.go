@@ -0,0 +1,140 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+type fakeMetrics struct {
+	mu       sync.Mutex
+	inFlight map[Operation]int
+	peak     int
+	metrics  []RequestMetric
+}
+
+func (m *fakeMetrics) AddInFlight(operation Operation, delta int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.inFlight == nil {
+		m.inFlight = map[Operation]int{}
+	}
+
+	m.inFlight[operation] += delta
+
+	if m.inFlight[operation] > m.peak {
+		m.peak = m.inFlight[operation]
+	}
+}
+
+func (m *fakeMetrics) ObserveRequest(metric RequestMetric) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.metrics = append(m.metrics, metric)
+}
+
+func TestMetrics(t *testing.T) {
+	calls := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+
+		if r.Method == http.MethodGet && calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		if r.Method == http.MethodPost && r.URL.Path != "/v2/tenants/my_tenant_1312/trees/tree_1/releases/release_1/executions" {
+			w.WriteHeader(http.StatusNotFound)
+
+			_, err := w.Write([]byte(`{"error": "tree_not_found"}`))
+			if err != nil {
+				t.Errorf("Error writing response httptest Server [%v]", err)
+			}
+
+			return
+		}
+
+		_, err := w.Write([]byte(`{"tree_version": "3", "response_type": "COMMON"}`))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v]", err)
+		}
+	}))
+	defer server.Close()
+
+	recorder := &fakeMetrics{}
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Multiplier: 1}
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL),
+		WithMetrics(recorder), WithRetryPolicy(policy))
+
+	if _, err := client.GetSessionInformation("session_1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.AddExecution("tree_1", "release_1", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.AddExecution("tree_2", "release_1", nil); !errors.Is(err, ErrTreeNotFound) {
+		t.Fatalf("want [%v] got [%v]", ErrTreeNotFound, err)
+	}
+
+	want := []RequestMetric{
+		{Operation: OperationSession, StatusCode: http.StatusOK, Retries: 2},
+		{Operation: OperationExecution, TreeID: "tree_1", ReleaseID: "release_1", StatusCode: http.StatusOK},
+		{
+			Operation:  OperationExecution,
+			TreeID:     "tree_2",
+			ReleaseID:  "release_1",
+			StatusCode: http.StatusNotFound,
+			ErrorKind:  "tree_not_found",
+		},
+	}
+
+	if diff := cmp.Diff(want, recorder.metrics, cmpopts.IgnoreFields(RequestMetric{}, "Duration")); diff != "" {
+		t.Errorf("metrics mismatch (-want +got):\n%s", diff)
+	}
+
+	if recorder.peak != 1 || recorder.inFlight[OperationExecution] != 0 {
+		t.Errorf("want peak [1] and nothing in flight got [%d] [%v]", recorder.peak, recorder.inFlight)
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestErrorKind(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{&APIError{StatusCode: http.StatusServiceUnavailable, Err: ErrRateLimit}, "rate_limit_reached"},
+		{fmt.Errorf("%w: tree [tree_1]", ErrCircuitOpen), "circuit_open"},
+		{fmt.Errorf("%w", context.Canceled), ErrorKindCanceled},
+		{context.DeadlineExceeded, ErrorKindTimeout},
+		{fmt.Errorf("%w", timeoutError{}), ErrorKindTimeout},
+		{errors.New("boom"), ErrorKindOther},
+	}
+
+	for _, c := range cases {
+		if got := ErrorKind(c.err); got != c.want {
+			t.Errorf("want [%s] got [%s] for [%v]", c.want, got, c.err)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// ErrUnknownOperation is returned for a Request with an unknown Operation.
+var ErrUnknownOperation = errors.New("unknown operation")
+
+// Operation is the logical Builder operation of a call.
+type Operation string
+
+// Operations of the client.
+const (
+	OperationExecution      Operation = "execution"
+	OperationAsyncExecution Operation = "async_execution"
+	OperationInteraction    Operation = "interaction"
+	OperationSession        Operation = "session"
+)
+
+// Request describes a call to Builder as seen by middleware.
+type Request struct {
+	Operation       Operation
+	TreeID          string
+	ReleaseID       string
+	SessionID       string
+	InteractionType string
+	Params          map[string]interface{}
+	// IdempotencyKey is sent as the Idempotency-Key header, see
+	// ContextWithIdempotencyKey and WithIdempotencyKeys.
+	IdempotencyKey string
+	// Header holds extra headers sent with the HTTP request.
+	Header http.Header
+}
+
+// Handler performs a call to Builder. Async executions return a Response
+// with only the request and session IDs.
+type Handler func(ctx context.Context, req *Request) (Response, error)
+
+// Middleware wraps a Handler, to audit, cache, measure or decorate calls.
+type Middleware func(next Handler) Handler
+
+// WithMiddleware adds middleware to the client, see API.Use.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(a *API) {
+		a.middleware = append(a.middleware, middleware...)
+	}
+}
+
+// Use adds middleware to the client, the first added is the outermost. The
+// middleware wraps the cache, coalescing, tracing, metrics and circuit
+// breaker of the client, so it also sees cached responses and ErrCircuitOpen.
+// Use must not be called concurrently with calls.
+func (a *API) Use(middleware ...Middleware) {
+	a.middleware = append(a.middleware, middleware...)
+	a.handler = a.chain()
+}
+
+// chain wraps the base request with the built-in middleware of the client
+// and then with the middleware added by the user, which is the outermost.
+func (a *API) chain() Handler {
+	handler := Handler(a.builderBaseRequest)
+
+	middleware := append(append([]Middleware{}, a.middleware...), a.builtinMiddleware()...)
+
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+
+	return handler
+}
+
+// builtinMiddleware returns the middleware enabled by the options of the
+// client, the first is the outermost.
+func (a *API) builtinMiddleware() []Middleware {
+	var middleware []Middleware
+
+	if a.responseCache != nil && len(a.responseCache.trees) > 0 {
+		middleware = append(middleware, a.cacheMiddleware)
+	}
+
+	if a.flights != nil {
+		middleware = append(middleware, a.coalesceMiddleware)
+	}
+
+	if a.tracer != nil {
+		middleware = append(middleware, a.traceMiddleware)
+	}
+
+	if a.metrics != nil {
+		middleware = append(middleware, a.metricsMiddleware)
+	}
+
+	if a.breaker != nil {
+		middleware = append(middleware, a.breakerMiddleware)
+	}
+
+	return middleware
+}
+
+// call sends req through the middleware chain.
+func (a *API) call(ctx context.Context, req *Request) (Response, error) {
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = IdempotencyKeyFromContext(ctx)
+	}
+
+	execution := req.Operation == OperationExecution || req.Operation == OperationAsyncExecution
+
+	if req.IdempotencyKey == "" && a.idempotencyKeys && execution {
+		key, err := newIdempotencyKey()
+		if err != nil {
+			return Response{}, err
+		}
+
+		req.IdempotencyKey = key
+	}
+
+	response, err := a.handler(ctx, req)
+	if err != nil && req.IdempotencyKey != "" {
+		return response, &idempotencyError{key: req.IdempotencyKey, err: err}
+	}
+
+	return response, err
+}
@@ -0,0 +1,188 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMiddlewareOrderAndRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Audit"); got != "outer" {
+			t.Errorf("want [%s] got [%s]", "outer", got)
+		}
+
+		w.Header().Set(headerSessionID, "session_1")
+		w.Header().Set(headerRequestID, "request_1")
+
+		_, err := w.Write([]byte(`{"tree_version": "3", "response_type": "COMMON"}`))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v]", err)
+		}
+	}))
+	defer server.Close()
+
+	var (
+		calls []string
+		seen  []Request
+	)
+
+	record := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, req *Request) (Response, error) {
+				calls = append(calls, name)
+				seen = append(seen, *req)
+
+				if name == "outer" {
+					req.Header.Set("X-Audit", name)
+				}
+
+				response, err := next(ctx, req)
+				calls = append(calls, name+" done")
+
+				return response, err
+			}
+		}
+	}
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL), WithMiddleware(record("outer")))
+	client.Use(record("inner"))
+
+	params := map[string]interface{}{"age": 10}
+
+	if _, err := client.AddExecution("tree_1", "release_1", params); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.GetSessionInformation("session_1"); err != nil {
+		t.Fatal(err)
+	}
+
+	requestID, err := client.AddAsyncExecution("tree_1", "release_1", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if requestID != "request_1" {
+		t.Errorf("want [%s] got [%s]", "request_1", requestID)
+	}
+
+	wantCalls := []string{
+		"outer", "inner", "inner done", "outer done",
+		"outer", "inner", "inner done", "outer done",
+		"outer", "inner", "inner done", "outer done",
+	}
+	if diff := cmp.Diff(wantCalls, calls); diff != "" {
+		t.Errorf("calls mismatch (-want +got):\n%s", diff)
+	}
+
+	operations := []Operation{seen[0].Operation, seen[2].Operation, seen[4].Operation}
+	wantOperations := []Operation{OperationExecution, OperationSession, OperationAsyncExecution}
+
+	if diff := cmp.Diff(wantOperations, operations); diff != "" {
+		t.Errorf("operations mismatch (-want +got):\n%s", diff)
+	}
+
+	if seen[0].TreeID != "tree_1" || seen[0].ReleaseID != "release_1" {
+		t.Errorf("want [tree_1 release_1] got [%s %s]", seen[0].TreeID, seen[0].ReleaseID)
+	}
+
+	if seen[2].SessionID != "session_1" {
+		t.Errorf("want [%s] got [%s]", "session_1", seen[2].SessionID)
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("no request must reach the server")
+	}))
+	defer server.Close()
+
+	errDenied := errors.New("denied")
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL))
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (Response, error) {
+			if req.Operation == OperationInteraction {
+				return Response{}, errDenied
+			}
+
+			return Response{TreeVersion: "cached"}, nil
+		}
+	})
+
+	_, err := client.AddInteraction("session_1", "continue", nil)
+	if !errors.Is(err, errDenied) {
+		t.Errorf("want [%v] got [%v]", errDenied, err)
+	}
+
+	response, err := client.AddExecution("tree_1", "release_1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.TreeVersion != "cached" {
+		t.Errorf("want [%s] got [%s]", "cached", response.TreeVersion)
+	}
+}
+
+func TestMiddlewareWrapsBuiltin(t *testing.T) {
+	var calls int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+
+		if strings.Contains(r.URL.Path, "tree_2") {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			_, err := w.Write([]byte(`{"error": "internal_builder_error"}`))
+			if err != nil {
+				t.Errorf("Error writing response httptest Server [%v]", err)
+			}
+
+			return
+		}
+
+		_, err := w.Write([]byte(`{"tree_version": "3", "response_type": "COMMON"}`))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v]", err)
+		}
+	}))
+	defer server.Close()
+
+	var seen []error
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL),
+		WithTreeCache("tree_1", CachePolicy{TTL: time.Minute}),
+		WithCircuitBreaker(CircuitBreaker{FailureThreshold: 1, CoolDown: time.Minute}))
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (Response, error) {
+			response, err := next(ctx, req)
+			seen = append(seen, err)
+
+			return response, err
+		}
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.AddExecution("tree_1", "release_1", nil); err != nil {
+			t.Fatal(err)
+		}
+
+		_, _ = client.AddExecution("tree_2", "release_1", nil)
+	}
+
+	if calls != 2 {
+		t.Errorf("want [2] calls got [%d]", calls)
+	}
+
+	if len(seen) != 4 || !errors.Is(seen[1], ErrBuilderAPI) || !errors.Is(seen[3], ErrCircuitOpen) {
+		t.Errorf("want the cached response and [%v] seen by the middleware got %v", ErrCircuitOpen, seen)
+	}
+}
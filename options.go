@@ -0,0 +1,42 @@
+package builder
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Option configures the client created by New.
+type Option func(*API)
+
+// WithHTTPClient sets the http.Client used for communications with Builder.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(a *API) {
+		if httpClient != nil {
+			a.httpClient = httpClient
+		}
+	}
+}
+
+// WithBaseURL sets the base URL of the Builder API, useful for staging
+// environments and test servers.
+func WithBaseURL(baseURL string) Option {
+	return func(a *API) {
+		a.apiURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithTimeout sets the timeout of the http.Client used by the library.
+// A user supplied http.Client is copied, never modified.
+func WithTimeout(timeout time.Duration) Option {
+	return func(a *API) {
+		a.timeout = timeout
+	}
+}
+
+// WithUserAgent appends a suffix to the builder-go user-agent.
+func WithUserAgent(suffix string) Option {
+	return func(a *API) {
+		a.userAgent = suffix
+	}
+}
@@ -0,0 +1,74 @@
+package builder
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewDefaults(t *testing.T) {
+	client := New("aabbcc", "my_tenant_1312")
+
+	if client.apiURL != APIURL {
+		t.Errorf("want [%s] got [%s]", APIURL, client.apiURL)
+	}
+
+	if client.httpClient.Timeout != defaultHTTPTimeout {
+		t.Errorf("want [%v] got [%v]", defaultHTTPTimeout, client.httpClient.Timeout)
+	}
+}
+
+func TestWithHTTPClientAndTimeout(t *testing.T) {
+	httpClient := &http.Client{Timeout: time.Second}
+
+	client := New("aabbcc", "my_tenant_1312", WithHTTPClient(httpClient), WithTimeout(5*time.Second))
+
+	if client.httpClient.Timeout != 5*time.Second {
+		t.Errorf("want [%v] got [%v]", 5*time.Second, client.httpClient.Timeout)
+	}
+
+	if httpClient.Timeout != time.Second {
+		t.Errorf("user http.Client must not be modified, got timeout [%v]", httpClient.Timeout)
+	}
+
+	client = New("aabbcc", "my_tenant_1312", WithHTTPClient(httpClient))
+	if client.httpClient != httpClient {
+		t.Error("want the user supplied http.Client")
+	}
+}
+
+func TestWithBaseURLAndUserAgent(t *testing.T) {
+	userAgent := fmt.Sprintf("builder-go/%s my-service/1.0", clientversion)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expectedURL := "/v2/tenants/my_tenant_1312/executions/c563cd9a979c46c18d8d892b122f5e38"
+		if r.URL.String() != expectedURL {
+			t.Errorf("got [%s] want [%s]", r.URL.String(), expectedURL)
+		}
+
+		reqAgent := r.Header.Get("User-Agent")
+		if reqAgent != userAgent {
+			t.Errorf("want [%s] got [%s]", userAgent, reqAgent)
+		}
+
+		n, err := w.Write([]byte(`{"tree_version": "3", "response_type": "COMMON"}`))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v][%d]", err, n)
+		}
+	}))
+
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL+"/"), WithUserAgent("my-service/1.0"))
+
+	response, err := client.GetSessionInformation("c563cd9a979c46c18d8d892b122f5e38")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if response.TreeVersion != "3" {
+		t.Errorf("want [3] got [%s]", response.TreeVersion)
+	}
+}
@@ -0,0 +1,97 @@
+// Package otelbuilder traces the calls of the Builder client with
+// OpenTelemetry.
+//
+//	client := builder.New(key, tenantID, builder.WithTracer(otelbuilder.New()))
+package otelbuilder
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/reevolute/builder-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the OpenTelemetry tracer.
+const instrumentationName = "github.com/reevolute/builder-go"
+
+// Tracer implements builder.Tracer with OpenTelemetry.
+type Tracer struct {
+	provider   trace.TracerProvider
+	propagator propagation.TextMapPropagator
+	tracer     trace.Tracer
+}
+
+var _ builder.Tracer = (*Tracer)(nil)
+
+// Option configures the Tracer created by New.
+type Option func(*Tracer)
+
+// WithTracerProvider sets the provider of the tracer, the global provider
+// is used by default.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(t *Tracer) {
+		t.provider = provider
+	}
+}
+
+// WithPropagator sets the propagator that writes the trace context into the
+// requests to Builder, W3C trace context is used by default.
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(t *Tracer) {
+		t.propagator = propagator
+	}
+}
+
+// New creates a Tracer.
+func New(opts ...Option) *Tracer {
+	t := Tracer{
+		provider:   otel.GetTracerProvider(),
+		propagator: propagation.TraceContext{},
+	}
+
+	for _, opt := range opts {
+		opt(&t)
+	}
+
+	t.tracer = t.provider.Tracer(instrumentationName)
+
+	return &t
+}
+
+// Start starts a client span.
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, builder.Span) {
+	ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+
+	return ctx, &Span{span: span}
+}
+
+// Inject writes the trace context of ctx into header.
+func (t *Tracer) Inject(ctx context.Context, header http.Header) {
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// Span implements builder.Span with an OpenTelemetry span.
+type Span struct {
+	span trace.Span
+}
+
+// SetAttribute sets a string attribute on the span.
+func (s *Span) SetAttribute(key, value string) {
+	s.span.SetAttributes(attribute.String(key, value))
+}
+
+// RecordError records err and marks the span as failed.
+func (s *Span) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// End ends the span.
+func (s *Span) End() {
+	s.span.End()
+}
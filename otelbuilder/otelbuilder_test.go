@@ -0,0 +1,98 @@
+package otelbuilder
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/reevolute/builder-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	var traceparent string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+
+		w.Header().Set("X-Session-Id", "session_1")
+		w.Header().Set("X-Trace-Id", "trace_1")
+
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error": "tree_not_found"}`))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"tree_version": "3", "response_type": "COMMON"}`))
+	}))
+	defer server.Close()
+
+	client := builder.New("aabbcc", "my_tenant_1312", builder.WithBaseURL(server.URL),
+		builder.WithTracer(New(WithTracerProvider(provider))))
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+
+	if _, err := client.AddExecutionContext(ctx, "tree_1", "release_1", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("want [2] spans got [%d]", len(spans))
+	}
+
+	span := spans[0]
+
+	if span.Name() != "builder.execution" {
+		t.Errorf("want [%s] got [%s]", "builder.execution", span.Name())
+	}
+
+	if span.SpanKind() != trace.SpanKindClient {
+		t.Errorf("want [%v] got [%v]", trace.SpanKindClient, span.SpanKind())
+	}
+
+	if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("want parent [%s] got [%s]", parent.SpanContext().SpanID(), span.Parent().SpanID())
+	}
+
+	want := "00-" + span.SpanContext().TraceID().String() + "-" + span.SpanContext().SpanID().String() + "-01"
+	if traceparent != want {
+		t.Errorf("want [%s] got [%s]", want, traceparent)
+	}
+
+	attributes := attribute.NewSet(span.Attributes()...)
+
+	for key, value := range map[string]string{
+		builder.AttributeTreeID:      "tree_1",
+		builder.AttributeSessionID:   "session_1",
+		builder.AttributeTraceID:     "trace_1",
+		builder.AttributeTreeVersion: "3",
+	} {
+		got, ok := attributes.Value(attribute.Key(key))
+		if !ok || got.AsString() != value {
+			t.Errorf("want [%s] for [%s] got [%s]", value, key, got.AsString())
+		}
+	}
+
+	if _, err := client.GetSessionInformation("session_1"); err == nil {
+		t.Fatal("want error")
+	}
+
+	span = recorder.Ended()[2]
+
+	if span.Status().Code != codes.Error {
+		t.Errorf("want [%v] got [%v]", codes.Error, span.Status().Code)
+	}
+}
@@ -0,0 +1,392 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// tagName is the struct tag read by EncodeParams and DecodeVars, its options
+// are omitempty and required.
+const tagName = "builder"
+
+var (
+	// ErrMissingVar is wrapped by DecodeError when a required var is missing.
+	ErrMissingVar = errors.New("missing_var")
+	// ErrVarType is wrapped by DecodeError when a var can not be stored in its field.
+	ErrVarType = errors.New("var_type_mismatch")
+	// ErrNotStruct is returned when a value is not a struct or a pointer to one.
+	ErrNotStruct = errors.New("not_a_struct")
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// DecodeError describes a var that could not be decoded by DecodeVars.
+type DecodeError struct {
+	// Var is the path of the var, nested vars are separated by dots.
+	Var string
+	// Field is the struct field the var was decoded into.
+	Field string
+	// Want is the type of the field and Got the type of the var, set on
+	// type mismatches.
+	Want string
+	Got  string
+	// Err is ErrMissingVar or ErrVarType.
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Want != "" {
+		return fmt.Sprintf("var %s into %s: %v, want %s got %s", e.Var, e.Field, e.Err, e.Want, e.Got)
+	}
+
+	return fmt.Sprintf("var %s into %s: %v", e.Var, e.Field, e.Err)
+}
+
+// Unwrap returns the cause of e.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+type fieldTag struct {
+	name      string
+	omitEmpty bool
+	required  bool
+}
+
+// parseTag returns the tag of field, ok is false for fields that must be skipped.
+func parseTag(field reflect.StructField) (fieldTag, bool) {
+	if field.PkgPath != "" {
+		return fieldTag{}, false
+	}
+
+	value := field.Tag.Get(tagName)
+	if value == "-" {
+		return fieldTag{}, false
+	}
+
+	parts := strings.Split(value, ",")
+
+	tag := fieldTag{name: parts[0]}
+	if tag.name == "" {
+		tag.name = field.Name
+	}
+
+	for _, option := range parts[1:] {
+		switch option {
+		case "omitempty":
+			tag.omitEmpty = true
+		case "required":
+			tag.required = true
+		}
+	}
+
+	return tag, true
+}
+
+// EncodeParams converts a struct into execution parameters. Fields are named
+// after their `builder:"name,omitempty"` tag or their name, untagged embedded
+// structs are flattened and nested structs become maps.
+func EncodeParams(v interface{}) (map[string]interface{}, error) {
+	if params, ok := v.(map[string]interface{}); ok {
+		return params, nil
+	}
+
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %T", ErrNotStruct, v)
+	}
+
+	params := make(map[string]interface{})
+	encodeStruct(value, params)
+
+	return params, nil
+}
+
+func encodeStruct(value reflect.Value, params map[string]interface{}) {
+	valueType := value.Type()
+
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+
+		tag, ok := parseTag(field)
+		if !ok {
+			continue
+		}
+
+		fieldValue := value.Field(i)
+
+		if field.Anonymous && field.Tag.Get(tagName) == "" {
+			for fieldValue.Kind() == reflect.Ptr && !fieldValue.IsNil() {
+				fieldValue = fieldValue.Elem()
+			}
+
+			if fieldValue.Kind() == reflect.Struct {
+				encodeStruct(fieldValue, params)
+			}
+
+			continue
+		}
+
+		if tag.omitEmpty && fieldValue.IsZero() {
+			continue
+		}
+
+		params[tag.name] = encodeValue(fieldValue)
+	}
+}
+
+func encodeValue(value reflect.Value) interface{} {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+
+		return encodeValue(value.Elem())
+	case reflect.Struct:
+		if value.Type() == timeType {
+			return value.Interface()
+		}
+
+		params := make(map[string]interface{})
+		encodeStruct(value, params)
+
+		return params
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return nil
+		}
+
+		list := make([]interface{}, value.Len())
+		for i := range list {
+			list[i] = encodeValue(value.Index(i))
+		}
+
+		return list
+	}
+
+	return value.Interface()
+}
+
+// DecodeVars stores the vars of the response in the struct pointed by out.
+// Fields are matched by their `builder:"name,required"` tag or their name.
+// Missing required vars and type mismatches return a *DecodeError.
+func (r Response) DecodeVars(out interface{}) error {
+	value := reflect.ValueOf(out)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %T", ErrNotStruct, out)
+	}
+
+	return decodeStruct(value.Elem(), r.Data.Vars, "")
+}
+
+func decodeStruct(value reflect.Value, vars map[string]interface{}, prefix string) error {
+	valueType := value.Type()
+
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+
+		tag, ok := parseTag(field)
+		if !ok {
+			continue
+		}
+
+		fieldValue := value.Field(i)
+
+		if field.Anonymous && field.Tag.Get(tagName) == "" {
+			if fieldValue.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct {
+				if fieldValue.IsNil() {
+					fieldValue.Set(reflect.New(field.Type.Elem()))
+				}
+
+				fieldValue = fieldValue.Elem()
+			}
+
+			if fieldValue.Kind() == reflect.Struct {
+				if err := decodeStruct(fieldValue, vars, prefix); err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		path := prefix + tag.name
+		fieldName := valueType.Name() + "." + field.Name
+
+		src, ok := vars[tag.name]
+		if !ok || src == nil {
+			if tag.required {
+				return &DecodeError{Var: path, Field: fieldName, Err: ErrMissingVar}
+			}
+
+			continue
+		}
+
+		if err := decodeValue(fieldValue, src, path, fieldName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func decodeValue(dst reflect.Value, src interface{}, path, fieldName string) error {
+	mismatch := func() error {
+		return &DecodeError{
+			Var:   path,
+			Field: fieldName,
+			Want:  dst.Type().String(),
+			Got:   fmt.Sprintf("%T", src),
+			Err:   ErrVarType,
+		}
+	}
+
+	if src == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+
+		return nil
+	}
+
+	srcValue := reflect.ValueOf(src)
+
+	switch dst.Kind() {
+	case reflect.Interface:
+		if !srcValue.Type().AssignableTo(dst.Type()) {
+			return mismatch()
+		}
+
+		dst.Set(srcValue)
+	case reflect.Ptr:
+		elem := reflect.New(dst.Type().Elem())
+		if err := decodeValue(elem.Elem(), src, path, fieldName); err != nil {
+			return err
+		}
+
+		dst.Set(elem)
+	case reflect.String:
+		if srcValue.Kind() != reflect.String {
+			return mismatch()
+		}
+
+		dst.SetString(srcValue.String())
+	case reflect.Bool:
+		if srcValue.Kind() != reflect.Bool {
+			return mismatch()
+		}
+
+		dst.SetBool(srcValue.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number, ok := toFloat(srcValue)
+		// the range is checked before converting, int64 of an out of range
+		// float is undefined.
+		if !ok || number != math.Trunc(number) || number < math.MinInt64 || number >= math.MaxInt64 ||
+			dst.OverflowInt(int64(number)) {
+			return mismatch()
+		}
+
+		dst.SetInt(int64(number))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		number, ok := toFloat(srcValue)
+		if !ok || number < 0 || number != math.Trunc(number) || number >= math.MaxUint64 ||
+			dst.OverflowUint(uint64(number)) {
+			return mismatch()
+		}
+
+		dst.SetUint(uint64(number))
+	case reflect.Float32, reflect.Float64:
+		number, ok := toFloat(srcValue)
+		if !ok || dst.OverflowFloat(number) {
+			return mismatch()
+		}
+
+		dst.SetFloat(number)
+	case reflect.Slice:
+		if srcValue.Kind() != reflect.Slice && srcValue.Kind() != reflect.Array {
+			return mismatch()
+		}
+
+		list := reflect.MakeSlice(dst.Type(), srcValue.Len(), srcValue.Len())
+		for i := 0; i < srcValue.Len(); i++ {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			if err := decodeValue(list.Index(i), srcValue.Index(i).Interface(), itemPath, fieldName); err != nil {
+				return err
+			}
+		}
+
+		dst.Set(list)
+	case reflect.Map:
+		if dst.Type().Key().Kind() != reflect.String || srcValue.Kind() != reflect.Map ||
+			srcValue.Type().Key().Kind() != reflect.String {
+			return mismatch()
+		}
+
+		m := reflect.MakeMapWithSize(dst.Type(), srcValue.Len())
+
+		iter := srcValue.MapRange()
+		for iter.Next() {
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			key := iter.Key().String()
+
+			if err := decodeValue(elem, iter.Value().Interface(), path+"."+key, fieldName); err != nil {
+				return err
+			}
+
+			m.SetMapIndex(reflect.ValueOf(key).Convert(dst.Type().Key()), elem)
+		}
+
+		dst.Set(m)
+	case reflect.Struct:
+		return decodeStructValue(dst, src, path, fieldName, mismatch)
+	default:
+		return mismatch()
+	}
+
+	return nil
+}
+
+func decodeStructValue(dst reflect.Value, src interface{}, path, fieldName string, mismatch func() error) error {
+	if dst.Type() == timeType {
+		text, ok := src.(string)
+		if !ok {
+			return mismatch()
+		}
+
+		parsed, err := time.Parse(time.RFC3339, text)
+		if err != nil {
+			return mismatch()
+		}
+
+		dst.Set(reflect.ValueOf(parsed))
+
+		return nil
+	}
+
+	vars, ok := src.(map[string]interface{})
+	if !ok {
+		return mismatch()
+	}
+
+	return decodeStruct(dst, vars, path+".")
+}
+
+func toFloat(value reflect.Value) (float64, bool) {
+	switch value.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,184 @@
+package builder
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type Address struct {
+	City string `builder:"city"`
+	Zip  string `builder:"zip,omitempty"`
+}
+
+type Audit struct {
+	Source string `builder:"source"`
+}
+
+type Applicant struct {
+	Audit
+	Name     string    `builder:"name"`
+	Age      int       `builder:"age,omitempty"`
+	Income   *float64  `builder:"income,omitempty"`
+	Tags     []string  `builder:"tags,omitempty"`
+	Address  Address   `builder:"address"`
+	Birthday time.Time `builder:"birthday,omitempty"`
+	Internal string    `builder:"-"`
+	secret   string
+}
+
+func TestEncodeParams(t *testing.T) {
+	income := 1500.5
+
+	applicant := Applicant{
+		Audit:    Audit{Source: "web"},
+		Name:     "Ana",
+		Income:   &income,
+		Tags:     []string{"new"},
+		Address:  Address{City: "Lima"},
+		Internal: "skip",
+		secret:   "skip",
+	}
+
+	params, err := EncodeParams(&applicant)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{
+		"source":  "web",
+		"name":    "Ana",
+		"income":  1500.5,
+		"tags":    []interface{}{"new"},
+		"address": map[string]interface{}{"city": "Lima"},
+	}
+
+	if diff := cmp.Diff(want, params); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := EncodeParams("red"); !errors.Is(err, ErrNotStruct) {
+		t.Errorf("want [%v] got [%v]", ErrNotStruct, err)
+	}
+}
+
+type Result struct {
+	ChildResponse string             `builder:"child_response,required"`
+	Score         int                `builder:"score"`
+	Ratio         float32            `builder:"ratio"`
+	Approved      *bool              `builder:"approved"`
+	Codes         []uint8            `builder:"codes"`
+	Limits        map[string]float64 `builder:"limits"`
+	Address       Address            `builder:"address"`
+	Raw           interface{}        `builder:"raw"`
+}
+
+func TestDecodeVars(t *testing.T) {
+	response := Response{
+		Data: ResponseData{
+			Vars: map[string]interface{}{
+				"child_response": "red",
+				"score":          float64(720),
+				"ratio":          0.5,
+				"approved":       true,
+				"codes":          []interface{}{float64(1), float64(2)},
+				"limits":         map[string]interface{}{"daily": float64(100)},
+				"address":        map[string]interface{}{"city": "Lima", "zip": "15001"},
+				"raw":            []interface{}{"x"},
+				"unknown":        "ignored",
+			},
+		},
+	}
+
+	var got Result
+	if err := response.DecodeVars(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	approved := true
+	want := Result{
+		ChildResponse: "red",
+		Score:         720,
+		Ratio:         0.5,
+		Approved:      &approved,
+		Codes:         []uint8{1, 2},
+		Limits:        map[string]float64{"daily": 100},
+		Address:       Address{City: "Lima", Zip: "15001"},
+		Raw:           []interface{}{"x"},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDecodeVarsErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		vars map[string]interface{}
+		want DecodeError
+	}{
+		{
+			"missing required",
+			map[string]interface{}{"score": float64(1)},
+			DecodeError{Var: "child_response", Field: "Result.ChildResponse", Err: ErrMissingVar},
+		},
+		{
+			"string into int",
+			map[string]interface{}{"child_response": "red", "score": "high"},
+			DecodeError{Var: "score", Field: "Result.Score", Want: "int", Got: "string", Err: ErrVarType},
+		},
+		{
+			"fraction into int",
+			map[string]interface{}{"child_response": "red", "score": 1.5},
+			DecodeError{Var: "score", Field: "Result.Score", Want: "int", Got: "float64", Err: ErrVarType},
+		},
+		{
+			"overflow",
+			map[string]interface{}{"child_response": "red", "codes": []interface{}{float64(300)}},
+			DecodeError{Var: "codes[0]", Field: "Result.Codes", Want: "uint8", Got: "float64", Err: ErrVarType},
+		},
+		{
+			"out of int range",
+			map[string]interface{}{"child_response": "red", "score": 1e20},
+			DecodeError{Var: "score", Field: "Result.Score", Want: "int", Got: "float64", Err: ErrVarType},
+		},
+		{
+			"out of uint range",
+			map[string]interface{}{"child_response": "red", "codes": []interface{}{1e20}},
+			DecodeError{Var: "codes[0]", Field: "Result.Codes", Want: "uint8", Got: "float64", Err: ErrVarType},
+		},
+		{
+			"nested",
+			map[string]interface{}{"child_response": "red", "address": map[string]interface{}{"city": 1}},
+			DecodeError{Var: "address.city", Field: "Address.City", Want: "string", Got: "int", Err: ErrVarType},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			response := Response{Data: ResponseData{Vars: tt.vars}}
+
+			var result Result
+
+			err := response.DecodeVars(&result)
+
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("want *DecodeError got [%v]", err)
+			}
+
+			if diff := cmp.Diff(tt.want, *decodeErr, cmp.Comparer(func(x, y error) bool {
+				return x == y
+			})); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if err := (Response{}).DecodeVars(Result{}); !errors.Is(err, ErrNotStruct) {
+		t.Errorf("want [%v] got [%v]", ErrNotStruct, err)
+	}
+}
@@ -0,0 +1,136 @@
+// Package prombuilder exports the metrics of the Builder client to
+// Prometheus.
+//
+//	recorder := prombuilder.New()
+//	prometheus.MustRegister(recorder)
+//
+//	client := builder.New(key, tenantID, builder.WithMetrics(recorder))
+package prombuilder
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/reevolute/builder-go"
+)
+
+// Recorder implements builder.MetricsRecorder with Prometheus metrics, it is
+// a prometheus.Collector to be registered by the caller.
+type Recorder struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+	inFlight *prometheus.GaugeVec
+	retries  *prometheus.CounterVec
+}
+
+var (
+	_ builder.MetricsRecorder = (*Recorder)(nil)
+	_ prometheus.Collector    = (*Recorder)(nil)
+)
+
+type config struct {
+	namespace string
+	buckets   []float64
+}
+
+// Option configures the Recorder created by New.
+type Option func(*config)
+
+// WithNamespace sets the namespace of the metrics, "builder" by default.
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// WithBuckets sets the buckets in seconds of the latency histogram.
+func WithBuckets(buckets []float64) Option {
+	return func(c *config) {
+		c.buckets = buckets
+	}
+}
+
+// New creates a Recorder.
+func New(opts ...Option) *Recorder {
+	c := config{
+		namespace: "builder",
+		buckets:   prometheus.DefBuckets,
+	}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	labels := []string{"operation", "tree", "release"}
+
+	return &Recorder{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: c.namespace,
+			Name:      "requests_total",
+			Help:      "Calls to Builder by operation, tree, release and HTTP status.",
+		}, append(labels, "status")),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: c.namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Latency of the calls to Builder, retries included.",
+			Buckets:   c.buckets,
+		}, append(labels, "status")),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: c.namespace,
+			Name:      "errors_total",
+			Help:      "Failed calls to Builder by error kind.",
+		}, append(labels, "kind")),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: c.namespace,
+			Name:      "in_flight_requests",
+			Help:      "Calls to Builder in progress.",
+		}, []string{"operation"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: c.namespace,
+			Name:      "retries_total",
+			Help:      "Attempts repeated by the retry policy of the client.",
+		}, labels),
+	}
+}
+
+// AddInFlight adds delta to the calls of operation in progress.
+func (r *Recorder) AddInFlight(operation builder.Operation, delta int) {
+	r.inFlight.WithLabelValues(string(operation)).Add(float64(delta))
+}
+
+// ObserveRequest records a finished call.
+func (r *Recorder) ObserveRequest(metric builder.RequestMetric) {
+	operation := string(metric.Operation)
+	status := strconv.Itoa(metric.StatusCode)
+
+	r.requests.WithLabelValues(operation, metric.TreeID, metric.ReleaseID, status).Inc()
+	r.duration.WithLabelValues(operation, metric.TreeID, metric.ReleaseID, status).
+		Observe(metric.Duration.Seconds())
+
+	if metric.ErrorKind != "" {
+		r.errors.WithLabelValues(operation, metric.TreeID, metric.ReleaseID, metric.ErrorKind).Inc()
+	}
+
+	if metric.Retries > 0 {
+		r.retries.WithLabelValues(operation, metric.TreeID, metric.ReleaseID).Add(float64(metric.Retries))
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (r *Recorder) Describe(ch chan<- *prometheus.Desc) {
+	r.requests.Describe(ch)
+	r.duration.Describe(ch)
+	r.errors.Describe(ch)
+	r.inFlight.Describe(ch)
+	r.retries.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (r *Recorder) Collect(ch chan<- prometheus.Metric) {
+	r.requests.Collect(ch)
+	r.duration.Collect(ch)
+	r.errors.Collect(ch)
+	r.inFlight.Collect(ch)
+	r.retries.Collect(ch)
+}
@@ -0,0 +1,65 @@
+package prombuilder
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/reevolute/builder-go"
+)
+
+func TestRecorder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "tree_2") {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error": "tree_not_found"}`))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"tree_version": "3", "response_type": "COMMON"}`))
+	}))
+	defer server.Close()
+
+	recorder := New()
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(recorder)
+
+	client := builder.New("aabbcc", "my_tenant_1312", builder.WithBaseURL(server.URL),
+		builder.WithMetrics(recorder))
+
+	if _, err := client.AddExecution("tree_1", "release_1", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.AddExecution("tree_2", "release_1", nil); err == nil {
+		t.Fatal("want error")
+	}
+
+	want := `
+# HELP builder_errors_total Failed calls to Builder by error kind.
+# TYPE builder_errors_total counter
+builder_errors_total{kind="tree_not_found",operation="execution",release="release_1",tree="tree_2"} 1
+# HELP builder_in_flight_requests Calls to Builder in progress.
+# TYPE builder_in_flight_requests gauge
+builder_in_flight_requests{operation="execution"} 0
+# HELP builder_requests_total Calls to Builder by operation, tree, release and HTTP status.
+# TYPE builder_requests_total counter
+builder_requests_total{operation="execution",release="release_1",status="200",tree="tree_1"} 1
+builder_requests_total{operation="execution",release="release_1",status="404",tree="tree_2"} 1
+`
+
+	err := testutil.GatherAndCompare(registry, strings.NewReader(want),
+		"builder_errors_total", "builder_in_flight_requests", "builder_requests_total")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count := testutil.CollectAndCount(recorder, "builder_request_duration_seconds"); count != 2 {
+		t.Errorf("want [2] got [%d]", count)
+	}
+}
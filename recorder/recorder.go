@@ -0,0 +1,335 @@
+// Package recorder provides an http.RoundTripper recording Builder traffic
+// into cassette files and replaying it, so code built on the builder client
+// can be tested without network access.
+//
+//	rec, err := recorder.New("testdata/cassette.json", recorder.ModeReplay)
+//	client := builder.New(key, tenant, builder.WithHTTPClient(rec.Client()))
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Mode sets whether a Recorder records or replays traffic.
+type Mode int
+
+const (
+	// ModeReplay answers requests from the cassette, without network access.
+	ModeReplay Mode = iota
+	// ModeRecord forwards requests and records them into the cassette.
+	ModeRecord
+	// ModeAuto replays when the cassette file exists and records otherwise.
+	ModeAuto
+)
+
+// redacted replaces the value of scrubbed headers.
+const redacted = "REDACTED"
+
+// ErrNoInteraction is returned when no recorded interaction matches a request in replay mode.
+var ErrNoInteraction = errors.New("no_recorded_interaction")
+
+// Request is a recorded request.
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette is the content of a cassette file.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Matcher reports whether a request, with its body already read, matches a
+// recorded request.
+type Matcher func(r *http.Request, body []byte, recorded Request) bool
+
+// MatchMethod matches requests with the same method.
+func MatchMethod(r *http.Request, body []byte, recorded Request) bool {
+	return r.Method == recorded.Method
+}
+
+// MatchPath matches requests with the same path and query.
+func MatchPath(r *http.Request, body []byte, recorded Request) bool {
+	return r.URL.RequestURI() == requestURI(recorded.URL)
+}
+
+// MatchBody matches requests with the same body, JSON bodies are compared
+// regardless of formatting and key order.
+func MatchBody(r *http.Request, body []byte, recorded Request) bool {
+	var got, want interface{}
+
+	if json.Unmarshal(body, &got) == nil && json.Unmarshal([]byte(recorded.Body), &want) == nil {
+		return reflect.DeepEqual(got, want)
+	}
+
+	return string(body) == recorded.Body
+}
+
+// MatchAll matches requests matched by every matcher.
+func MatchAll(matchers ...Matcher) Matcher {
+	return func(r *http.Request, body []byte, recorded Request) bool {
+		for _, match := range matchers {
+			if !match(r, body, recorded) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// Option configures a Recorder.
+type Option func(*Recorder)
+
+// WithTransport sets the transport used to record, http.DefaultTransport by default.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.transport = transport
+	}
+}
+
+// WithMatcher sets how requests are matched on replay, by default method, path and body.
+func WithMatcher(matcher Matcher) Option {
+	return func(r *Recorder) {
+		r.matcher = matcher
+	}
+}
+
+// WithScrubHeaders adds headers to scrub from the cassette, Authorization is always scrubbed.
+func WithScrubHeaders(names ...string) Option {
+	return func(r *Recorder) {
+		r.scrub = append(r.scrub, names...)
+	}
+}
+
+// Recorder is an http.RoundTripper recording or replaying a cassette, safe for
+// concurrent use. Recorded interactions are replayed once each, in order, so
+// repeated requests such as session lookups get their successive responses.
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+	matcher   Matcher
+	scrub     []string
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// New creates a Recorder for the cassette file at path, in replay mode the
+// cassette is loaded.
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	r := Recorder{
+		path:      path,
+		mode:      mode,
+		transport: http.DefaultTransport,
+		matcher:   MatchAll(MatchMethod, MatchPath, MatchBody),
+		scrub:     []string{"Authorization"},
+	}
+
+	for _, opt := range opts {
+		opt(&r)
+	}
+
+	if r.mode == ModeAuto {
+		r.mode = ModeRecord
+
+		if _, err := os.Stat(path); err == nil {
+			r.mode = ModeReplay
+		}
+	}
+
+	if r.mode == ModeReplay {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+
+		if err := json.Unmarshal(content, &r.cassette); err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+
+	return &r, nil
+}
+
+// Mode returns the mode of the recorder, ModeAuto resolved.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Client returns an http.Client using the recorder as transport.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(request *http.Request) (*http.Response, error) {
+	var body []byte
+
+	if request.Body != nil {
+		content, err := io.ReadAll(request.Body)
+		if err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+
+		request.Body.Close()
+
+		body = content
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(request, body)
+	}
+
+	return r.record(request, body)
+}
+
+func (r *Recorder) replay(request *http.Request, body []byte) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || !r.matcher(request, body, interaction.Request) {
+			continue
+		}
+
+		r.used[i] = true
+
+		status := interaction.Response.StatusCode
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+			StatusCode:    status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Header.Clone(),
+			Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       request,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, request.Method, request.URL.RequestURI())
+}
+
+func (r *Recorder) record(request *http.Request, body []byte) (*http.Response, error) {
+	forwarded := request.Clone(request.Context())
+	forwarded.Body = io.NopCloser(bytes.NewReader(body))
+	forwarded.ContentLength = int64(len(body))
+
+	response, err := r.transport.RoundTrip(forwarded)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	content, err := io.ReadAll(response.Body)
+	response.Body.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	response.Body = io.NopCloser(bytes.NewReader(content))
+
+	interaction := Interaction{
+		Request: Request{
+			Method: request.Method,
+			URL:    request.URL.String(),
+			Header: r.scrubbed(request.Header),
+			Body:   string(body),
+		},
+		Response: Response{
+			StatusCode: response.StatusCode,
+			Header:     r.scrubbed(response.Header),
+			Body:       string(content),
+		},
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+
+	return response, nil
+}
+
+func (r *Recorder) scrubbed(header http.Header) http.Header {
+	clone := header.Clone()
+
+	for _, name := range r.scrub {
+		if clone.Get(name) != "" {
+			clone.Set(name, redacted)
+		}
+	}
+
+	return clone
+}
+
+// Cassette returns a copy of the recorded interactions.
+func (r *Recorder) Cassette() Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	interactions := make([]Interaction, len(r.cassette.Interactions))
+	copy(interactions, r.cassette.Interactions)
+
+	return Cassette{Interactions: interactions}
+}
+
+// Save writes the recorded interactions to the cassette file, it does nothing
+// in replay mode.
+func (r *Recorder) Save() error {
+	if r.mode == ModeReplay {
+		return nil
+	}
+
+	content, err := json.MarshalIndent(r.Cassette(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	if err := os.WriteFile(r.path, content, 0o600); err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	return nil
+}
+
+func requestURI(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	return parsed.RequestURI()
+}
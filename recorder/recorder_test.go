@@ -0,0 +1,150 @@
+package recorder
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/reevolute/builder-go"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Session-Id", "c563cd9a979c46c18d8d892b122f5e38")
+		w.Header().Set("X-Request-Id", "c563cd9a979c46c18d8d892b122f5e39")
+
+		if r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		n, err := w.Write([]byte(`{"tree_version": "3", "response_type": "COMMON", "data": {"vars": {"color": "red"}}}`))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v][%d]", err, n)
+		}
+	}))
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	rec, err := New(path, ModeAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rec.Mode() != ModeRecord {
+		t.Fatalf("want record mode without cassette got [%v]", rec.Mode())
+	}
+
+	client := builder.New("aabbcc", "my_tenant_1312", builder.WithBaseURL(server.URL),
+		builder.WithHTTPClient(rec.Client()))
+
+	parameters := map[string]interface{}{
+		"color": "red",
+	}
+
+	recorded, err := client.AddExecution("color_pick", "production", parameters)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, recordedErr := client.GetSessionInformation("unknown")
+
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	server.Close()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(content), "aabbcc") {
+		t.Error("the api key must be scrubbed from the cassette")
+	}
+
+	rec, err = New(path, ModeAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rec.Mode() != ModeReplay {
+		t.Fatalf("want replay mode with cassette got [%v]", rec.Mode())
+	}
+
+	client = builder.New("aabbcc", "my_tenant_1312", builder.WithBaseURL(server.URL),
+		builder.WithHTTPClient(rec.Client()))
+
+	replayed, err := client.AddExecution("color_pick", "production", parameters)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(recorded, replayed); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	_, err = client.GetSessionInformation("unknown")
+	if !errors.Is(err, builder.ErrTenantNotFound) || !errors.Is(recordedErr, builder.ErrTenantNotFound) {
+		t.Errorf("want [%v] got [%v]", builder.ErrTenantNotFound, err)
+	}
+
+	_, err = client.AddExecution("color_pick", "production", parameters)
+	if !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("interactions are replayed once, want [%v] got [%v]", ErrNoInteraction, err)
+	}
+}
+
+func TestReplayMatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	cassette := `{"interactions": [{
+		"request": {"method": "POST", "url": "https://builder.api.reevolute.com/v2/tenants/t/trees/a/releases/p/executions",
+		            "body": "{\"parameters\": {\"color\": \"red\"}, \"type\": \"sync\"}"},
+		"response": {"status_code": 200, "body": "{\"tree_version\": \"3\"}"}
+	}]}`
+
+	if err := os.WriteFile(path, []byte(cassette), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		matcher Matcher
+		params  map[string]interface{}
+		match   bool
+	}{
+		{"same body", nil, map[string]interface{}{"color": "red"}, true},
+		{"other body", nil, map[string]interface{}{"color": "blue"}, false},
+		{"ignoring body", MatchAll(MatchMethod, MatchPath), map[string]interface{}{"color": "blue"}, true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []Option
+			if tt.matcher != nil {
+				opts = append(opts, WithMatcher(tt.matcher))
+			}
+
+			rec, err := New(path, ModeReplay, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			client := builder.New("aabbcc", "t", builder.WithHTTPClient(rec.Client()))
+
+			_, err = client.AddExecution("a", "p", tt.params)
+			if matched := err == nil; matched != tt.match {
+				t.Errorf("want match [%v] got error [%v]", tt.match, err)
+			}
+		})
+	}
+}
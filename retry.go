@@ -0,0 +1,125 @@
+package builder
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const headerIdempotencyKey = "Idempotency-Key"
+
+// RetryPolicy configures the automatic retries of failed calls. Only network
+// errors and 429, 502, 503 and 504 responses are retried. Executions and
+// interactions are not idempotent, they are only retried when the call carries
+// an idempotency key, see ContextWithIdempotencyKey.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// A value lower than 2 disables retries.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts. A Retry-After longer than
+	// MaxBackoff stops the retries.
+	MaxBackoff time.Duration
+	// Multiplier grows the backoff after every attempt.
+	Multiplier float64
+	// Jitter is the fraction, between 0 and 1, of the backoff that is randomized.
+	Jitter float64
+}
+
+// DefaultRetryPolicy returns a policy of 3 attempts with exponential backoff
+// starting at 500ms.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// WithRetryPolicy enables automatic retries, by default calls are not retried.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(a *API) {
+		a.retryPolicy = policy
+	}
+}
+
+type idempotencyKeyCtx struct{}
+
+// ContextWithIdempotencyKey returns a copy of ctx carrying an idempotency key,
+// sent on every attempt of the call made with it.
+func ContextWithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtx{}, key)
+}
+
+// IdempotencyKeyFromContext returns the idempotency key carried by ctx.
+func IdempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyCtx{}).(string)
+
+	return key
+}
+
+// backoff returns the wait before the retry following the given attempt,
+// attempts start at 1.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	wait := float64(p.InitialBackoff)
+	if p.Multiplier > 1 {
+		wait *= math.Pow(p.Multiplier, float64(attempt-1))
+	}
+
+	if p.MaxBackoff > 0 && wait > float64(p.MaxBackoff) {
+		wait = float64(p.MaxBackoff)
+	}
+
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		wait -= wait * jitter * rand.Float64()
+	}
+
+	return time.Duration(wait)
+}
+
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// retryAfter parses the Retry-After header, in seconds or as an HTTP date.
+func retryAfter(response *http.Response) time.Duration {
+	value := response.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+
+	return 0
+}
+
+func sleep(ctx context.Context, wait time.Duration) error {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
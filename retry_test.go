@@ -0,0 +1,232 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     10 * time.Millisecond,
+		Multiplier:     2,
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+	}
+
+	cases := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{5, time.Second},
+	}
+
+	for _, tt := range cases {
+		if got := policy.backoff(tt.attempt); got != tt.want {
+			t.Errorf("attempt [%d] want [%v] got [%v]", tt.attempt, tt.want, got)
+		}
+	}
+
+	policy.Jitter = 0.5
+
+	for i := 0; i < 100; i++ {
+		got := policy.backoff(1)
+		if got < 50*time.Millisecond || got > 100*time.Millisecond {
+			t.Fatalf("backoff with jitter out of range [%v]", got)
+		}
+	}
+}
+
+func TestRetrySessionInformation(t *testing.T) {
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		n, err := w.Write([]byte(`{"tree_version": "3", "response_type": "COMMON"}`))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v][%d]", err, n)
+		}
+	}))
+
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL), WithRetryPolicy(testRetryPolicy()))
+
+	response, err := client.GetSessionInformation("c563cd9a979c46c18d8d892b122f5e38")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.TreeVersion != "3" {
+		t.Errorf("want [3] got [%s]", response.TreeVersion)
+	}
+
+	if calls != 3 {
+		t.Errorf("want [3] calls got [%d]", calls)
+	}
+}
+
+func TestRetryExecutionRequiresIdempotencyKey(t *testing.T) {
+	var calls int32
+
+	expectedBody := `{"parameters":{"color":"red"},"type":"sync"}`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("error reading body [%v]", err)
+		}
+
+		if string(body) != expectedBody {
+			t.Errorf("want body [%s] got [%s]", expectedBody, body)
+		}
+
+		call := atomic.AddInt32(&calls, 1)
+
+		if key := r.Header.Get(headerIdempotencyKey); call > 1 && key != "key-1" {
+			t.Errorf("want idempotency key [key-1] got [%s]", key)
+		}
+
+		if call < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+
+			return
+		}
+
+		n, err := w.Write([]byte(`{"tree_version": "3", "response_type": "COMMON"}`))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v][%d]", err, n)
+		}
+	}))
+
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL), WithRetryPolicy(testRetryPolicy()))
+
+	parameters := map[string]interface{}{
+		"color": "red",
+	}
+
+	_, err := client.AddExecution("color_pick", "production", parameters)
+	if !errors.Is(err, ErrBuilderAPI) {
+		t.Errorf("want [%v] got [%v]", ErrBuilderAPI, err)
+	}
+
+	if calls != 1 {
+		t.Errorf("executions without idempotency key must not be retried, got [%d] calls", calls)
+	}
+
+	ctx := ContextWithIdempotencyKey(context.Background(), "key-1")
+
+	_, err = client.AddExecutionContext(ctx, "color_pick", "production", parameters)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if calls != 3 {
+		t.Errorf("want [3] calls got [%d]", calls)
+	}
+}
+
+func TestRetryAfterLongerThanMaxBackoff(t *testing.T) {
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL), WithRetryPolicy(testRetryPolicy()))
+
+	_, err := client.GetSessionInformation("c563cd9a979c46c18d8d892b122f5e38")
+	if !errors.Is(err, ErrRateLimit) {
+		t.Errorf("want [%v] got [%v]", ErrRateLimit, err)
+	}
+
+	if calls != 1 {
+		t.Errorf("want [1] call got [%d]", calls)
+	}
+}
+
+func TestRetryTransportErrors(t *testing.T) {
+	var calls int32
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(200 * time.Millisecond):
+			}
+
+			return
+		}
+
+		n, err := w.Write([]byte(`{"tree_version": "3", "response_type": "COMMON"}`))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v][%d]", err, n)
+		}
+	}))
+	defer slow.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(slow.URL), WithRetryPolicy(testRetryPolicy()),
+		WithTimeout(20*time.Millisecond))
+
+	if _, err := client.GetSessionInformation("session_1"); err != nil {
+		t.Fatalf("want the client timeouts retried got [%v]", err)
+	}
+
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Errorf("want [3] calls got [%d]", got)
+	}
+
+	var connections int32
+
+	untrusted := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	untrusted.Config.ErrorLog = log.New(io.Discard, "", 0)
+	untrusted.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&connections, 1)
+		}
+	}
+	untrusted.StartTLS()
+	defer untrusted.Close()
+
+	client = New("aabbcc", "my_tenant_1312", WithBaseURL(untrusted.URL), WithRetryPolicy(testRetryPolicy()))
+
+	_, err := client.GetSessionInformation("session_1")
+	if err == nil || IsRetryable(err) {
+		t.Errorf("want a certificate error not retryable got [%v]", err)
+	}
+
+	if got := atomic.LoadInt32(&connections); got != 1 {
+		t.Errorf("want [1] attempt got [%d]", got)
+	}
+}
@@ -0,0 +1,206 @@
+package builder
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// ResponseTypeCommon is the response type of a finished execution.
+	ResponseTypeCommon = "COMMON"
+	// ResponseTypeError is the response type of a failed execution.
+	ResponseTypeError = "ERROR"
+
+	// InteractionTypeSync is the interaction type of a sync execution.
+	InteractionTypeSync = "sync"
+	// InteractionTypeContinue is the interaction type answering a tree waiting for input.
+	InteractionTypeContinue = "continue"
+)
+
+// Final reports whether the response ends its session, trees waiting for more
+// input answer with other response types.
+func (r Response) Final() bool {
+	switch r.ResponseType {
+	case "", ResponseTypeCommon, ResponseTypeError:
+		return true
+	}
+
+	return false
+}
+
+// Interaction is a step of a session.
+type Interaction struct {
+	Type     string
+	Params   map[string]interface{}
+	Response Response
+	At       time.Time
+}
+
+// Session tracks a multi-step conversation with a tree. It is safe for
+// concurrent use, interactions are sent one at a time.
+type Session struct {
+	client    ContextClient
+	treeID    string
+	releaseID string
+
+	// callMu serializes the calls to Builder, mu guards the state.
+	callMu  sync.Mutex
+	mu      sync.RWMutex
+	id      string
+	last    Response
+	history []Interaction
+	// loaded is false until the first response of the session is known.
+	loaded bool
+}
+
+// StartSession adds a sync execution and returns the session it started.
+func StartSession(ctx context.Context, client ContextClient, treeID, releaseID string,
+	params map[string]interface{}) (*Session, error) {
+	response, err := client.AddExecutionContext(ctx, treeID, releaseID, params)
+	if err != nil {
+		return nil, err
+	}
+
+	s := Session{
+		client:    client,
+		treeID:    treeID,
+		releaseID: releaseID,
+	}
+
+	s.record(InteractionTypeSync, params, response)
+
+	return &s, nil
+}
+
+// ResumeSession returns a Session for an existing session ID, its state is
+// empty until Refresh or Continue are called.
+func ResumeSession(client ContextClient, sessionID string) *Session {
+	return &Session{
+		client: client,
+		id:     sessionID,
+	}
+}
+
+// StartSession adds a sync execution and returns the session it started.
+func (a *API) StartSession(ctx context.Context, treeID, releaseID string,
+	params map[string]interface{}) (*Session, error) {
+	return StartSession(ctx, a, treeID, releaseID, params)
+}
+
+// ID returns the session ID.
+func (s *Session) ID() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.id
+}
+
+// TreeID returns the tree of the session, empty for resumed sessions.
+func (s *Session) TreeID() string {
+	return s.treeID
+}
+
+// ReleaseID returns the release of the session, empty for resumed sessions.
+func (s *Session) ReleaseID() string {
+	return s.releaseID
+}
+
+// Last returns the last response of the session.
+func (s *Session) Last() Response {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.last
+}
+
+// TreeVersion returns the tree version reported by the last response.
+func (s *Session) TreeVersion() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.last.TreeVersion
+}
+
+// History returns the interactions of the session, starting with the execution.
+func (s *Session) History() []Interaction {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	history := make([]Interaction, len(s.history))
+	copy(history, s.history)
+
+	return history
+}
+
+// Done reports whether the last response ends the session, it is false for
+// resumed sessions until Refresh or Continue are called.
+func (s *Session) Done() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.loaded && s.last.Final()
+}
+
+func (s *Session) isLoaded() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.loaded
+}
+
+// Continue adds an interaction to the session.
+func (s *Session) Continue(ctx context.Context, interactionType string,
+	params map[string]interface{}) (Response, error) {
+	s.callMu.Lock()
+	defer s.callMu.Unlock()
+
+	response, err := s.client.AddInteractionContext(ctx, s.ID(), interactionType, params)
+	if err != nil {
+		return Response{}, err
+	}
+
+	s.record(interactionType, params, response)
+
+	return response, nil
+}
+
+// Refresh gets the current state of the session from Builder.
+func (s *Session) Refresh(ctx context.Context) (Response, error) {
+	s.callMu.Lock()
+	defer s.callMu.Unlock()
+
+	response, err := s.client.GetSessionInformationContext(ctx, s.ID())
+	if err != nil {
+		return Response{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.setLast(response)
+
+	return response, nil
+}
+
+func (s *Session) record(interactionType string, params map[string]interface{}, response Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.setLast(response)
+	s.history = append(s.history, Interaction{
+		Type:     interactionType,
+		Params:   params,
+		Response: response,
+		At:       time.Now(),
+	})
+}
+
+func (s *Session) setLast(response Response) {
+	if response.SessionID != "" {
+		s.id = response.SessionID
+	}
+
+	s.last = response
+	s.loaded = true
+}
@@ -0,0 +1,131 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func newSessionServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	sessionID := "c563cd9a979c46c18d8d892b122f5e38"
+
+	write := func(w http.ResponseWriter, body string) {
+		w.Header().Set(headerSessionID, sessionID)
+
+		n, err := w.Write([]byte(body))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v][%d]", err, n)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/tenants/my_tenant_1312/trees/color_pick/releases/production/executions",
+		func(w http.ResponseWriter, r *http.Request) {
+			write(w, `{"tree_version": "3", "response_type": "INPUT", "data": {"vars": {"step": 1}}}`)
+		})
+	mux.HandleFunc(fmt.Sprintf("/v2/tenants/my_tenant_1312/executions/%s/interactions", sessionID),
+		func(w http.ResponseWriter, r *http.Request) {
+			write(w, `{"tree_version": "4", "response_type": "COMMON", "data": {"vars": {"step": 2}}}`)
+		})
+	mux.HandleFunc("/v2/tenants/my_tenant_1312/executions/"+sessionID,
+		func(w http.ResponseWriter, r *http.Request) {
+			write(w, `{"tree_version": "4", "response_type": "COMMON", "data": {"vars": {"step": 3}}}`)
+		})
+
+	return httptest.NewServer(mux)
+}
+
+func TestSession(t *testing.T) {
+	server := newSessionServer(t)
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL))
+	ctx := context.Background()
+
+	session, err := client.StartSession(ctx, "color_pick", "production", map[string]interface{}{"color": "red"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if session.ID() != "c563cd9a979c46c18d8d892b122f5e38" {
+		t.Errorf("want session id [c563cd9a979c46c18d8d892b122f5e38] got [%s]", session.ID())
+	}
+
+	if session.Done() {
+		t.Error("an INPUT response must not end the session")
+	}
+
+	if _, err := session.Continue(ctx, InteractionTypeContinue, map[string]interface{}{"size": "L"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !session.Done() {
+		t.Error("a COMMON response must end the session")
+	}
+
+	if session.TreeVersion() != "4" {
+		t.Errorf("want tree version [4] got [%s]", session.TreeVersion())
+	}
+
+	history := session.History()
+	if len(history) != 2 {
+		t.Fatalf("want [2] interactions got [%d]", len(history))
+	}
+
+	if history[0].Type != InteractionTypeSync || history[1].Type != InteractionTypeContinue {
+		t.Errorf("want types [sync continue] got [%s %s]", history[0].Type, history[1].Type)
+	}
+
+	if history[1].Params["size"] != "L" {
+		t.Errorf("want params [size=L] got [%v]", history[1].Params)
+	}
+
+	response, err := session.Refresh(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Data.Vars["step"] != float64(3) || session.Last().Data.Vars["step"] != float64(3) {
+		t.Errorf("want step [3] got [%v]", session.Last().Data.Vars["step"])
+	}
+
+	if len(session.History()) != 2 {
+		t.Error("refresh must not add interactions")
+	}
+}
+
+func TestSessionConcurrent(t *testing.T) {
+	server := newSessionServer(t)
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL))
+	session := ResumeSession(client, "c563cd9a979c46c18d8d892b122f5e38")
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if _, err := session.Continue(context.Background(), InteractionTypeContinue, nil); err != nil {
+				t.Error(err)
+			}
+
+			_ = session.Done()
+			_ = session.History()
+		}()
+	}
+
+	wg.Wait()
+
+	if len(session.History()) != 10 {
+		t.Errorf("want [10] interactions got [%d]", len(session.History()))
+	}
+}
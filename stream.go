@@ -0,0 +1,145 @@
+package builder
+
+import (
+	"context"
+	"sync"
+)
+
+// Job is an execution of ExecuteStream.
+type Job struct {
+	TreeID    string
+	ReleaseID string
+	Params    map[string]interface{}
+	// Metadata is passed through to the result untouched, such as a message offset.
+	Metadata interface{}
+}
+
+// StreamResult is the outcome of a Job.
+type StreamResult struct {
+	Job      Job
+	Response Response
+	Err      error
+}
+
+// StreamOptions configures ExecuteStream.
+type StreamOptions struct {
+	// Concurrency is the number of executions run at the same time, 4 by default.
+	Concurrency int
+	// Ordered emits the results in the order of the jobs, otherwise as they complete.
+	Ordered bool
+}
+
+// ExecuteStream adds a sync execution for every job received from in and
+// sends its result to the returned channel. At most Concurrency jobs run at
+// the same time, jobs are not read while results are not consumed. The
+// returned channel is closed once in is closed and every result was sent, or
+// when ctx is done, then pending results are dropped.
+func ExecuteStream(ctx context.Context, client ContextClient, in <-chan Job, opts StreamOptions) <-chan StreamResult {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	out := make(chan StreamResult)
+	slots := make(chan struct{}, concurrency)
+
+	// pending holds the result channels of running jobs in the order of the
+	// jobs, only used when ordered.
+	pending := make(chan chan StreamResult, concurrency)
+
+	var wg sync.WaitGroup
+
+	send := func(ch chan<- StreamResult, result StreamResult) {
+		select {
+		case ch <- result:
+		case <-ctx.Done():
+		}
+	}
+
+	run := func(job Job, ch chan<- StreamResult) {
+		defer wg.Done()
+		defer func() { <-slots }()
+
+		response, err := client.AddExecutionContext(ctx, job.TreeID, job.ReleaseID, job.Params)
+		send(ch, StreamResult{Job: job, Response: response, Err: err})
+	}
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		defer close(pending)
+
+		for {
+			var job Job
+
+			select {
+			case <-ctx.Done():
+				return
+			case j, ok := <-in:
+				if !ok {
+					return
+				}
+
+				job = j
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case slots <- struct{}{}:
+			}
+
+			if !opts.Ordered {
+				wg.Add(1)
+
+				go run(job, out)
+
+				continue
+			}
+
+			result := make(chan StreamResult, 1)
+
+			select {
+			case <-ctx.Done():
+				<-slots
+
+				return
+			case pending <- result:
+			}
+
+			wg.Add(1)
+
+			go run(job, result)
+		}
+	}()
+
+	if opts.Ordered {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for result := range pending {
+				select {
+				case r := <-result:
+					send(out, r)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+// ExecuteStream adds a sync execution for every job received from in, see ExecuteStream.
+func (a *API) ExecuteStream(ctx context.Context, in <-chan Job, opts StreamOptions) <-chan StreamResult {
+	return ExecuteStream(ctx, a, in, opts)
+}
@@ -0,0 +1,138 @@
+package builder
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newStreamServer answers executions after a delay of params["delay"] milliseconds.
+func newStreamServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var requestBody struct {
+			Parameters map[string]interface{} `json:"parameters"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			t.Errorf("Error request body %v", err)
+		}
+
+		delay, _ := requestBody.Parameters["delay"].(float64)
+
+		select {
+		case <-time.After(time.Duration(delay) * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+
+		response := map[string]interface{}{
+			"response_type": "COMMON",
+			"data":          map[string]interface{}{"vars": requestBody.Parameters},
+		}
+
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			t.Errorf("Error writing response httptest Server [%v]", err)
+		}
+	}))
+}
+
+func streamJobs(delays ...int) <-chan Job {
+	in := make(chan Job, len(delays))
+
+	for i, delay := range delays {
+		in <- Job{
+			TreeID:    "color_pick",
+			ReleaseID: "production",
+			Params:    map[string]interface{}{"delay": delay},
+			Metadata:  i,
+		}
+	}
+
+	close(in)
+
+	return in
+}
+
+func TestExecuteStreamOrdered(t *testing.T) {
+	server := newStreamServer(t)
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL))
+
+	out := client.ExecuteStream(context.Background(), streamJobs(30, 1, 20, 1, 10), StreamOptions{
+		Concurrency: 3,
+		Ordered:     true,
+	})
+
+	next := 0
+
+	for result := range out {
+		if result.Err != nil {
+			t.Fatal(result.Err)
+		}
+
+		if result.Job.Metadata != next {
+			t.Errorf("want job [%d] got [%v]", next, result.Job.Metadata)
+		}
+
+		next++
+	}
+
+	if next != 5 {
+		t.Errorf("want [5] results got [%d]", next)
+	}
+}
+
+func TestExecuteStreamUnordered(t *testing.T) {
+	server := newStreamServer(t)
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL))
+
+	out := client.ExecuteStream(context.Background(), streamJobs(50, 1), StreamOptions{Concurrency: 2})
+
+	first := <-out
+	if first.Job.Metadata != 1 {
+		t.Errorf("want the fastest job first got [%v]", first.Job.Metadata)
+	}
+
+	if first.Response.Data.Vars["delay"] != float64(1) {
+		t.Errorf("want the response of the job got [%v]", first.Response.Data.Vars)
+	}
+
+	<-out
+
+	if _, ok := <-out; ok {
+		t.Error("the channel must be closed after the last result")
+	}
+}
+
+func TestExecuteStreamCancel(t *testing.T) {
+	server := newStreamServer(t)
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL))
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	in := make(chan Job)
+	out := client.ExecuteStream(ctx, in, StreamOptions{Concurrency: 2, Ordered: true})
+
+	in <- Job{TreeID: "color_pick", ReleaseID: "production", Params: map[string]interface{}{"delay": 1000}}
+
+	cancel()
+
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("the channel must be closed when the context is done")
+	case _, ok := <-out:
+		for ok {
+			_, ok = <-out
+		}
+	}
+}
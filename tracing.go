@@ -0,0 +1,92 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// Span attributes set by the client.
+const (
+	AttributeOperation    = "builder.operation"
+	AttributeTreeID       = "builder.tree_id"
+	AttributeReleaseID    = "builder.release_id"
+	AttributeSessionID    = "builder.session_id"
+	AttributeRequestID    = "builder.request_id"
+	AttributeTraceID      = "builder.trace_id"
+	AttributeTreeVersion  = "builder.tree_version"
+	AttributeResponseType = "builder.response_type"
+	AttributeStatusCode   = "http.status_code"
+)
+
+// Tracer creates spans around the calls of the client, see the
+// otelbuilder module for an OpenTelemetry implementation.
+type Tracer interface {
+	// Start starts a span named name as a child of the span in ctx.
+	Start(ctx context.Context, name string) (context.Context, Span)
+	// Inject writes the trace context of ctx into header, usually as a W3C
+	// traceparent header.
+	Inject(ctx context.Context, header http.Header)
+}
+
+// Span is a single traced call.
+type Span interface {
+	SetAttribute(key, value string)
+	RecordError(err error)
+	End()
+}
+
+// WithTracer traces every call of the client with tracer.
+func WithTracer(tracer Tracer) Option {
+	return func(a *API) {
+		a.tracer = tracer
+	}
+}
+
+// traceMiddleware starts a span per call and propagates it to Builder.
+func (a *API) traceMiddleware(next Handler) Handler {
+	return func(ctx context.Context, req *Request) (Response, error) {
+		ctx, span := a.tracer.Start(ctx, "builder."+string(req.Operation))
+		defer span.End()
+
+		setAttribute(span, AttributeOperation, string(req.Operation))
+		setAttribute(span, AttributeTreeID, req.TreeID)
+		setAttribute(span, AttributeReleaseID, req.ReleaseID)
+		setAttribute(span, AttributeSessionID, req.SessionID)
+
+		a.tracer.Inject(ctx, req.Header)
+
+		response, err := next(ctx, req)
+		if err != nil {
+			var apiErr *APIError
+			if errors.As(err, &apiErr) {
+				setAttribute(span, AttributeStatusCode, strconv.Itoa(apiErr.StatusCode))
+				setAttribute(span, AttributeRequestID, apiErr.RequestID)
+				setAttribute(span, AttributeTraceID, apiErr.TraceID)
+			}
+
+			span.RecordError(err)
+
+			return response, err
+		}
+
+		if req.SessionID == "" {
+			setAttribute(span, AttributeSessionID, response.SessionID)
+		}
+
+		setAttribute(span, AttributeRequestID, response.RequestID)
+		setAttribute(span, AttributeTraceID, response.TraceID)
+		setAttribute(span, AttributeTreeVersion, response.TreeVersion)
+		setAttribute(span, AttributeResponseType, response.ResponseType)
+
+		return response, nil
+	}
+}
+
+// setAttribute sets the attribute on span when value is not empty.
+func setAttribute(span Span, key, value string) {
+	if value != "" {
+		span.SetAttribute(key, value)
+	}
+}
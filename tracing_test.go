@@ -0,0 +1,133 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+type fakeSpan struct {
+	name       string
+	attributes map[string]string
+	err        error
+	ended      bool
+}
+
+func (s *fakeSpan) SetAttribute(key, value string) { s.attributes[key] = value }
+func (s *fakeSpan) RecordError(err error)          { s.err = err }
+func (s *fakeSpan) End()                           { s.ended = true }
+
+type fakeTracer struct {
+	mu    sync.Mutex
+	spans []*fakeSpan
+}
+
+func (t *fakeTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	span := &fakeSpan{name: name, attributes: map[string]string{}}
+	t.spans = append(t.spans, span)
+
+	return ctx, span
+}
+
+func (t *fakeTracer) Inject(ctx context.Context, header http.Header) {
+	header.Set("traceparent", testTraceparent)
+}
+
+func TestTracerExecution(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("traceparent"); got != testTraceparent {
+			t.Errorf("want [%s] got [%s]", testTraceparent, got)
+		}
+
+		w.Header().Set(headerSessionID, "session_1")
+		w.Header().Set(headerRequestID, "request_1")
+		w.Header().Set(headerTraceID, "trace_1")
+
+		_, err := w.Write([]byte(`{"tree_version": "3", "response_type": "COMMON"}`))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v]", err)
+		}
+	}))
+	defer server.Close()
+
+	tracer := &fakeTracer{}
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL), WithTracer(tracer))
+
+	if _, err := client.AddExecution("tree_1", "release_1", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tracer.spans) != 1 {
+		t.Fatalf("want [1] spans got [%d]", len(tracer.spans))
+	}
+
+	span := tracer.spans[0]
+
+	if span.name != "builder.execution" {
+		t.Errorf("want [%s] got [%s]", "builder.execution", span.name)
+	}
+
+	if !span.ended {
+		t.Error("want span ended")
+	}
+
+	want := map[string]string{
+		AttributeOperation:    "execution",
+		AttributeTreeID:       "tree_1",
+		AttributeReleaseID:    "release_1",
+		AttributeSessionID:    "session_1",
+		AttributeRequestID:    "request_1",
+		AttributeTraceID:      "trace_1",
+		AttributeTreeVersion:  "3",
+		AttributeResponseType: "COMMON",
+	}
+
+	if diff := cmp.Diff(want, span.attributes); diff != "" {
+		t.Errorf("attributes mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTracerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerTraceID, "trace_2")
+		w.WriteHeader(http.StatusNotFound)
+
+		_, err := w.Write([]byte(`{"error": "tree_not_found"}`))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v]", err)
+		}
+	}))
+	defer server.Close()
+
+	tracer := &fakeTracer{}
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL), WithTracer(tracer))
+
+	_, err := client.GetSessionInformation("session_1")
+	if !errors.Is(err, ErrTreeNotFound) {
+		t.Fatalf("want [%v] got [%v]", ErrTreeNotFound, err)
+	}
+
+	span := tracer.spans[0]
+
+	if !errors.Is(span.err, ErrTreeNotFound) {
+		t.Errorf("want [%v] got [%v]", ErrTreeNotFound, span.err)
+	}
+
+	if got := span.attributes[AttributeTraceID]; got != "trace_2" {
+		t.Errorf("want [%s] got [%s]", "trace_2", got)
+	}
+
+	if got := span.attributes[AttributeStatusCode]; got != "404" {
+		t.Errorf("want [%s] got [%s]", "404", got)
+	}
+}
@@ -0,0 +1,134 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultPollInterval    = time.Second
+	defaultPollMaxInterval = 10 * time.Second
+	defaultPollMultiplier  = 1.5
+)
+
+// ErrExecutionFailed is wrapped by ExecutionError when Builder reports an
+// error for the execution.
+var ErrExecutionFailed = errors.New("execution_failed")
+
+// ExecutionError is returned by WaitForExecution when the execution failed or
+// the context was done before the result was available.
+type ExecutionError struct {
+	// SessionID identifies the async execution.
+	SessionID string
+	// Attempts is the number of polls made.
+	Attempts int
+	// Response is the result reported by Builder, empty while pending.
+	Response Response
+	// Err is ErrExecutionFailed or the error of the context.
+	Err error
+}
+
+func (e *ExecutionError) Error() string {
+	return fmt.Sprintf("execution %s: %v", e.SessionID, e.Err)
+}
+
+// Unwrap returns the cause of e.
+func (e *ExecutionError) Unwrap() error {
+	return e.Err
+}
+
+// WaitOption configures the polling of WaitForExecution.
+type WaitOption func(*waitConfig)
+
+type waitConfig struct {
+	interval    time.Duration
+	maxInterval time.Duration
+	multiplier  float64
+}
+
+// WithPollInterval sets the wait before the first poll, 1s by default.
+func WithPollInterval(interval time.Duration) WaitOption {
+	return func(c *waitConfig) {
+		c.interval = interval
+	}
+}
+
+// WithPollBackoff grows the interval by multiplier after every poll, up to
+// maxInterval. By default 1.5 and 10s.
+func WithPollBackoff(multiplier float64, maxInterval time.Duration) WaitOption {
+	return func(c *waitConfig) {
+		c.multiplier = multiplier
+		c.maxInterval = maxInterval
+	}
+}
+
+// WaitForExecution polls the session of an async execution, see
+// AddAsyncExecutionResponse, until Builder reports its response type.
+// Retryable errors are polled again. A failed execution or a done context
+// return an *ExecutionError.
+func (a *API) WaitForExecution(ctx context.Context, sessionID string, opts ...WaitOption) (Response, error) {
+	config := waitConfig{
+		interval:    defaultPollInterval,
+		maxInterval: defaultPollMaxInterval,
+		multiplier:  defaultPollMultiplier,
+	}
+
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	interval := config.interval
+
+	for attempt := 1; ; attempt++ {
+		if err := sleep(ctx, interval); err != nil {
+			return Response{}, &ExecutionError{SessionID: sessionID, Attempts: attempt - 1, Err: err}
+		}
+
+		response, err := a.GetSessionInformationContext(ctx, sessionID)
+		if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+			return Response{}, &ExecutionError{SessionID: sessionID, Attempts: attempt, Err: ctxErr}
+		}
+
+		if err != nil && !IsRetryable(err) {
+			return Response{}, err
+		}
+
+		if err == nil && response.ResponseType != "" {
+			if response.ResponseType == ResponseTypeError ||
+				(response.Data.ErrorCode != "" && response.Data.ErrorCode != "0") {
+				return response, &ExecutionError{
+					SessionID: sessionID,
+					Attempts:  attempt,
+					Response:  response,
+					Err:       ErrExecutionFailed,
+				}
+			}
+
+			return response, nil
+		}
+
+		if config.multiplier > 1 {
+			interval = time.Duration(float64(interval) * config.multiplier)
+		}
+
+		if config.maxInterval > 0 && interval > config.maxInterval {
+			interval = config.maxInterval
+		}
+	}
+}
+
+// AddExecutionAndWait adds an async execution to Builder and waits for its result.
+func (a *API) AddExecutionAndWait(ctx context.Context, treeID, deploymentID string,
+	params map[string]interface{}, opts ...WaitOption) (Response, error) {
+	execution, err := a.AddAsyncExecutionResponseContext(ctx, treeID, deploymentID, params)
+	if err != nil {
+		return Response{}, err
+	}
+
+	response, err := a.WaitForExecution(ctx, execution.SessionID, opts...)
+	response.IdempotencyKey = execution.IdempotencyKey
+
+	return response, err
+}
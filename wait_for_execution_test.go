@@ -0,0 +1,140 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const waitSessionID = "c563cd9a979c46c18d8d892b122f5e38"
+
+// newWaitServer answers the session of an async execution with results,
+// the last one is repeated.
+func newWaitServer(t *testing.T, polls *int32, results ...string) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/tenants/my_tenant_1312/trees/color_pick/releases/production/executions",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(headerSessionID, waitSessionID)
+			w.Header().Set(headerRequestID, "c563cd9a979c46c18d8d892b122f5e39")
+			w.WriteHeader(http.StatusCreated)
+		})
+	mux.HandleFunc("/v2/tenants/my_tenant_1312/executions/"+waitSessionID, func(w http.ResponseWriter, r *http.Request) {
+		poll := int(atomic.AddInt32(polls, 1))
+		if poll > len(results) {
+			poll = len(results)
+		}
+
+		w.Header().Set(headerSessionID, waitSessionID)
+
+		n, err := w.Write([]byte(results[poll-1]))
+		if err != nil {
+			t.Errorf("Error writing response httptest Server [%v][%d]", err, n)
+		}
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestAddExecutionAndWait(t *testing.T) {
+	var polls int32
+
+	server := newWaitServer(t, &polls, `{}`, `{}`, `
+		{
+		  "tree_version": "3",
+		  "response_type": "COMMON",
+		  "data": {
+		    "description": "function evaluation",
+		    "error_code": "0",
+		    "vars": {
+		      "child_response": "red"
+		    }
+		  }
+		}
+		`)
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL))
+
+	parameters := map[string]interface{}{
+		"color": "red",
+	}
+
+	response, err := client.AddExecutionAndWait(context.Background(), "color_pick", "production", parameters,
+		WithPollInterval(time.Millisecond), WithPollBackoff(2, 4*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payloadResponse := Response{
+		SessionID:    waitSessionID,
+		TreeVersion:  "3",
+		ResponseType: "COMMON",
+		Data: ResponseData{
+			Description: "function evaluation",
+			ErrorCode:   "0",
+			Vars: map[string]interface{}{
+				"child_response": "red",
+			},
+		},
+	}
+
+	if diff := cmp.Diff(payloadResponse, response); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	if got := atomic.LoadInt32(&polls); got != 3 {
+		t.Errorf("want [3] polls got [%d]", got)
+	}
+}
+
+func TestWaitForExecutionFailed(t *testing.T) {
+	var polls int32
+
+	server := newWaitServer(t, &polls, `{"tree_version": "3", "response_type": "ERROR",
+		"data": {"description": "division by zero", "error_code": "12"}}`)
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL))
+
+	response, err := client.WaitForExecution(context.Background(), waitSessionID, WithPollInterval(time.Millisecond))
+
+	var execErr *ExecutionError
+	if !errors.As(err, &execErr) || !errors.Is(err, ErrExecutionFailed) {
+		t.Fatalf("want [%v] got [%v]", ErrExecutionFailed, err)
+	}
+
+	if execErr.SessionID != waitSessionID || response.Data.ErrorCode != "12" {
+		t.Errorf("unexpected error [%+v] response [%+v]", execErr, response)
+	}
+}
+
+func TestWaitForExecutionContext(t *testing.T) {
+	var polls int32
+
+	server := newWaitServer(t, &polls, `{}`)
+	defer server.Close()
+
+	client := New("aabbcc", "my_tenant_1312", WithBaseURL(server.URL))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	_, err := client.WaitForExecution(ctx, waitSessionID, WithPollInterval(5*time.Millisecond))
+
+	var execErr *ExecutionError
+	if !errors.As(err, &execErr) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want [%v] got [%v]", context.DeadlineExceeded, err)
+	}
+
+	if execErr.Attempts == 0 {
+		t.Error("want at least one poll")
+	}
+}
@@ -0,0 +1,280 @@
+// Package webhook receives the callbacks Builder sends when an async
+// execution finishes.
+//
+// Every callback is signed with a secret shared with Builder: the
+// X-Builder-Signature header holds "sha256=" followed by the hex encoded
+// HMAC-SHA256 of the X-Builder-Timestamp header, a dot and the body.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/reevolute/builder-go"
+)
+
+const (
+	// HeaderSignature is the header carrying the signature of the callback.
+	HeaderSignature = "X-Builder-Signature"
+	// HeaderTimestamp is the header carrying the unix time the callback was sent.
+	HeaderTimestamp = "X-Builder-Timestamp"
+
+	signaturePrefix = "sha256="
+
+	// DefaultTolerance is the maximum age of a callback.
+	DefaultTolerance = 5 * time.Minute
+
+	maxBodySize = 1 << 20
+)
+
+var (
+	// ErrInvalidSignature is returned when the signature does not match the body.
+	ErrInvalidSignature = errors.New("invalid_signature")
+	// ErrInvalidTimestamp is returned when the timestamp is missing, malformed
+	// or outside the tolerance, a replayed callback.
+	ErrInvalidTimestamp = errors.New("invalid_timestamp")
+	// ErrDuplicate is returned for a callback already delivered, a replay
+	// within the tolerance.
+	ErrDuplicate = errors.New("duplicate_callback")
+)
+
+// Callback receives the result of an async execution.
+type Callback func(ctx context.Context, response builder.Response)
+
+// Option configures a Handler.
+type Option func(*Handler)
+
+// WithTolerance sets the maximum age of a callback, DefaultTolerance by default.
+func WithTolerance(tolerance time.Duration) Option {
+	return func(h *Handler) {
+		h.tolerance = tolerance
+	}
+}
+
+// WithFallback sets the callback for request IDs without a registered callback.
+func WithFallback(callback Callback) Option {
+	return func(h *Handler) {
+		h.fallback = callback
+	}
+}
+
+// Handler is an http.Handler verifying Builder callbacks and dispatching them
+// to the callback registered for their request ID.
+type Handler struct {
+	secret    []byte
+	tolerance time.Duration
+	fallback  Callback
+	now       func() time.Time
+
+	mu        sync.Mutex
+	callbacks map[string]Callback
+	// delivered holds the request IDs delivered and until when a replay of
+	// their callback passes Verify.
+	delivered map[string]time.Time
+}
+
+// NewHandler creates a Handler verifying callbacks with the shared secret.
+func NewHandler(secret string, opts ...Option) *Handler {
+	h := Handler{
+		secret:    []byte(secret),
+		tolerance: DefaultTolerance,
+		now:       time.Now,
+		callbacks: make(map[string]Callback),
+		delivered: make(map[string]time.Time),
+	}
+
+	for _, opt := range opts {
+		opt(&h)
+	}
+
+	return &h
+}
+
+// Register sets the callback for the request ID returned by AddAsyncExecution.
+// The callback is called once and then removed.
+func (h *Handler) Register(requestID string, callback Callback) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.callbacks[requestID] = callback
+}
+
+// Unregister removes the callback of requestID.
+func (h *Handler) Unregister(requestID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.callbacks, requestID)
+}
+
+// take returns the callback of requestID and records it delivered until
+// expires, ErrDuplicate if it already was.
+func (h *Handler) take(requestID string, expires time.Time) (Callback, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.now()
+
+	for id, until := range h.delivered {
+		if now.After(until) {
+			delete(h.delivered, id)
+		}
+	}
+
+	if _, ok := h.delivered[requestID]; ok {
+		return nil, ErrDuplicate
+	}
+
+	callback, ok := h.callbacks[requestID]
+	if ok {
+		delete(h.callbacks, requestID)
+	} else {
+		callback = h.fallback
+	}
+
+	if callback != nil {
+		h.delivered[requestID] = expires
+	}
+
+	return callback, nil
+}
+
+type payload struct {
+	RequestID    string               `json:"request_id"`
+	SessionID    string               `json:"session_id"`
+	TreeVersion  string               `json:"tree_version"`
+	ResponseType string               `json:"response_type"`
+	Data         builder.ResponseData `json:"data"`
+}
+
+// ServeHTTP verifies the callback and dispatches it. Callbacks for unknown
+// request IDs are answered with 404 so Builder delivers them again, this covers
+// callbacks arriving before Register. A callback already delivered is
+// rejected with 409 while its timestamp is within the tolerance.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, "error reading body", http.StatusBadRequest)
+
+		return
+	}
+
+	if err := h.Verify(r.Header, body); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+
+		return
+	}
+
+	response, err := Parse(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	callback, err := h.take(response.RequestID, h.expires(r.Header))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+
+		return
+	}
+
+	if callback == nil {
+		http.Error(w, "unknown request id", http.StatusNotFound)
+
+		return
+	}
+
+	callback(r.Context(), response)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Verify checks the signature and the timestamp of a callback.
+func (h *Handler) Verify(header http.Header, body []byte) error {
+	unix, err := timestamp(header)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+
+	timestamp := time.Unix(unix, 0)
+
+	if age := h.now().Sub(timestamp); age > h.tolerance || age < -h.tolerance {
+		return ErrInvalidTimestamp
+	}
+
+	signature := strings.TrimPrefix(header.Get(HeaderSignature), signaturePrefix)
+
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal(got, mac(h.secret, unix, body)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// expires returns when a callback sent with header stops passing Verify.
+func (h *Handler) expires(header http.Header) time.Time {
+	unix, _ := timestamp(header)
+
+	return time.Unix(unix, 0).Add(h.tolerance)
+}
+
+func timestamp(header http.Header) (int64, error) {
+	return strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+}
+
+// Parse decodes the body of a callback into a builder.Response.
+func Parse(body []byte) (builder.Response, error) {
+	var p payload
+
+	if err := json.Unmarshal(body, &p); err != nil {
+		return builder.Response{}, fmt.Errorf("%w", err)
+	}
+
+	response := builder.Response{
+		SessionID:    p.SessionID,
+		RequestID:    p.RequestID,
+		TreeVersion:  p.TreeVersion,
+		ResponseType: p.ResponseType,
+		Data:         p.Data,
+	}
+
+	return response, nil
+}
+
+// Sign returns the X-Builder-Signature value of a callback sent at timestamp,
+// useful to test handlers.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	return signaturePrefix + hex.EncodeToString(mac([]byte(secret), timestamp.Unix(), body))
+}
+
+func mac(secret []byte, timestamp int64, body []byte) []byte {
+	h := hmac.New(sha256.New, secret)
+	fmt.Fprintf(h, "%d.", timestamp)
+	h.Write(body)
+
+	return h.Sum(nil)
+}
@@ -0,0 +1,203 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/reevolute/builder-go"
+)
+
+const testSecret = "whsec_aabbcc"
+
+var testBody = []byte(`
+{
+  "request_id": "c563cd9a979c46c18d8d892b122f5e39",
+  "session_id": "c563cd9a979c46c18d8d892b122f5e38",
+  "tree_version": "3",
+  "response_type": "COMMON",
+  "data": {
+    "description": "function evaluation",
+    "error_code": "0",
+    "vars": {
+      "child_response": "red"
+    }
+  }
+}
+`)
+
+func post(t *testing.T, url string, timestamp time.Time, signature string, body []byte) int {
+	t.Helper()
+
+	request, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp.Unix(), 10))
+	request.Header.Set(HeaderSignature, signature)
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer response.Body.Close()
+
+	return response.StatusCode
+}
+
+func TestHandlerDispatch(t *testing.T) {
+	handler := NewHandler(testSecret)
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	received := make(chan builder.Response, 1)
+
+	handler.Register("c563cd9a979c46c18d8d892b122f5e39", func(ctx context.Context, response builder.Response) {
+		received <- response
+	})
+
+	now := time.Now()
+
+	status := post(t, server.URL, now, Sign(testSecret, now, testBody), testBody)
+	if status != http.StatusNoContent {
+		t.Fatalf("want [%d] got [%d]", http.StatusNoContent, status)
+	}
+
+	payloadResponse := builder.Response{
+		SessionID:    "c563cd9a979c46c18d8d892b122f5e38",
+		RequestID:    "c563cd9a979c46c18d8d892b122f5e39",
+		TreeVersion:  "3",
+		ResponseType: "COMMON",
+		Data: builder.ResponseData{
+			Description: "function evaluation",
+			ErrorCode:   "0",
+			Vars: map[string]interface{}{
+				"child_response": "red",
+			},
+		},
+	}
+
+	if diff := cmp.Diff(payloadResponse, <-received); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	status = post(t, server.URL, now, Sign(testSecret, now, testBody), testBody)
+	if status != http.StatusConflict {
+		t.Errorf("a delivered callback must be rejected, want [%d] got [%d]", http.StatusConflict, status)
+	}
+}
+
+func TestHandlerRejects(t *testing.T) {
+	now := time.Now()
+	stale := now.Add(-10 * time.Minute)
+
+	cases := []struct {
+		name      string
+		timestamp time.Time
+		signature string
+		body      []byte
+		status    int
+	}{
+		{"wrong secret", now, Sign("other", now, testBody), testBody, http.StatusUnauthorized},
+		{"malformed signature", now, "sha256=zz", testBody, http.StatusUnauthorized},
+		{"tampered body", now, Sign(testSecret, now, testBody), []byte(`{"request_id": "x"}`), http.StatusUnauthorized},
+		{"replay", stale, Sign(testSecret, stale, testBody), testBody, http.StatusUnauthorized},
+		{"invalid json", now, Sign(testSecret, now, []byte("{")), []byte("{"), http.StatusBadRequest},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(testSecret)
+			handler.Register("c563cd9a979c46c18d8d892b122f5e39", func(ctx context.Context, response builder.Response) {
+				t.Error("callback must not be called")
+			})
+
+			server := httptest.NewServer(handler)
+			defer server.Close()
+
+			if status := post(t, server.URL, tt.timestamp, tt.signature, tt.body); status != tt.status {
+				t.Errorf("want [%d] got [%d]", tt.status, status)
+			}
+		})
+	}
+}
+
+func TestHandlerFallback(t *testing.T) {
+	var got string
+
+	handler := NewHandler(testSecret, WithTolerance(time.Minute), WithFallback(
+		func(ctx context.Context, response builder.Response) {
+			got = response.RequestID
+		}))
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	now := time.Now()
+
+	if status := post(t, server.URL, now, Sign(testSecret, now, testBody), testBody); status != http.StatusNoContent {
+		t.Fatalf("want [%d] got [%d]", http.StatusNoContent, status)
+	}
+
+	if got != "c563cd9a979c46c18d8d892b122f5e39" {
+		t.Errorf("want fallback called with [c563cd9a979c46c18d8d892b122f5e39] got [%s]", got)
+	}
+
+	response, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("want [%d] got [%d]", http.StatusMethodNotAllowed, response.StatusCode)
+	}
+}
+
+func TestHandlerFallbackReplay(t *testing.T) {
+	var calls int
+
+	handler := NewHandler(testSecret, WithTolerance(time.Minute), WithFallback(
+		func(ctx context.Context, response builder.Response) {
+			calls++
+		}))
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	now := time.Now()
+	signature := Sign(testSecret, now, testBody)
+
+	if status := post(t, server.URL, now, signature, testBody); status != http.StatusNoContent {
+		t.Fatalf("want [%d] got [%d]", http.StatusNoContent, status)
+	}
+
+	if status := post(t, server.URL, now, signature, testBody); status != http.StatusConflict {
+		t.Errorf("want the replay rejected with [%d] got [%d]", http.StatusConflict, status)
+	}
+
+	if calls != 1 {
+		t.Errorf("want [1] fallback calls got [%d]", calls)
+	}
+
+	// the record expires with the tolerance of the delivered callback.
+	later := now.Add(2 * time.Minute)
+	handler.now = func() time.Time { return later }
+
+	if status := post(t, server.URL, later, Sign(testSecret, later, testBody), testBody); status != http.StatusNoContent {
+		t.Errorf("want [%d] got [%d]", http.StatusNoContent, status)
+	}
+
+	if len(handler.delivered) != 1 {
+		t.Errorf("want the expired records removed got [%d]", len(handler.delivered))
+	}
+}